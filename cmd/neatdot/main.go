@@ -0,0 +1,93 @@
+// Package main converts a saved NEAT genome into a Graphviz DOT graph. The
+// genome can be in the JSON or the binary form. With the -svg flag the graph is
+// rendered by the dot command, which should be installed separately.
+//
+// Usage:
+//
+//	neatdot [-o output] [-svg] [genome]
+//
+// If the genome is not given, it is read from the standard input.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+
+	"github.com/arsham/neuragene/internal/brain"
+)
+
+func main() {
+	output := flag.String("o", "", "write the output to this file instead of the standard output")
+	svg := flag.Bool("svg", false, "render the graph as SVG with the dot command")
+	flag.Parse()
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0), *output, *svg); err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+func run(input, output string, svg bool) error {
+	var r io.Reader = os.Stdin
+	if input != "" {
+		f, err := os.Open(input)
+		if err != nil {
+			return fmt.Errorf("opening genome: %w", err)
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				slog.Error("closing genome", "error", err)
+			}
+		}()
+		r = f
+	}
+	n, err := brain.ReadNEAT(r)
+	if err != nil {
+		return fmt.Errorf("reading genome: %w", err)
+	}
+
+	graph := &bytes.Buffer{}
+	if err := n.WriteDOT(graph); err != nil {
+		return err
+	}
+	data := graph.Bytes()
+	if svg {
+		data, err = render(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	if output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(output, data, 0o600); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	return nil
+}
+
+// render converts the DOT graph into SVG.
+func render(graph []byte) ([]byte, error) {
+	cmd := exec.Command("dot", "-Tsvg")
+	cmd.Stdin = bytes.NewReader(graph)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) > 0 {
+			return nil, fmt.Errorf("rendering SVG: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("rendering SVG: %w", err)
+	}
+	return out, nil
+}
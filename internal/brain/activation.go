@@ -0,0 +1,161 @@
+package brain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Activation is the function that is applied to the weighted sum of a node's
+// inputs. The zero value is the Identity activation.
+type Activation uint8
+
+const (
+	// Identity returns the input unchanged.
+	Identity Activation = iota
+	// Sigmoid squashes the input into the (0, 1) range.
+	Sigmoid
+	// Tanh squashes the input into the (-1, 1) range.
+	Tanh
+	// ReLU returns zero for negative inputs and the input otherwise.
+	ReLU
+	// LeakyReLU is like ReLU but it lets a small portion of the negative
+	// inputs pass through.
+	LeakyReLU
+	// Step returns 1 for positive inputs and 0 otherwise.
+	Step
+	// Gaussian returns 1 at zero and approaches 0 as the input moves away.
+	Gaussian
+	// Sine returns the sine of the input.
+	Sine
+	// Clamped limits the input to the [-1, 1] range.
+	Clamped
+)
+
+// activations is the registry of all available activations. The index of each
+// entry is its Activation value. The derivative receives the input x and the
+// output y of the activation, so the activations can use whichever is cheaper.
+var activations = [...]struct {
+	fn         func(float64) float64
+	derivative func(x, y float64) float64
+	name       string
+}{
+	Identity: {
+		name:       "identity",
+		fn:         func(x float64) float64 { return x },
+		derivative: func(_, _ float64) float64 { return 1 },
+	},
+	Sigmoid: {
+		name:       "sigmoid",
+		fn:         func(x float64) float64 { return 1.0 / (1 + math.Exp(-x)) },
+		derivative: func(_, y float64) float64 { return y * (1 - y) },
+	},
+	Tanh: {
+		name:       "tanh",
+		fn:         math.Tanh,
+		derivative: func(_, y float64) float64 { return 1 - y*y },
+	},
+	ReLU: {
+		name: "relu",
+		fn:   func(x float64) float64 { return math.Max(0, x) },
+		derivative: func(x, _ float64) float64 {
+			if x > 0 {
+				return 1
+			}
+			return 0
+		},
+	},
+	LeakyReLU: {
+		name: "leaky_relu",
+		fn: func(x float64) float64 {
+			if x < 0 {
+				return 0.01 * x
+			}
+			return x
+		},
+		derivative: func(x, _ float64) float64 {
+			if x < 0 {
+				return 0.01
+			}
+			return 1
+		},
+	},
+	Step: {
+		name: "step",
+		fn: func(x float64) float64 {
+			if x > 0 {
+				return 1
+			}
+			return 0
+		},
+		// The step function is flat everywhere except at zero, therefore it
+		// doesn't pass any gradients.
+		derivative: func(_, _ float64) float64 { return 0 },
+	},
+	Gaussian: {
+		name:       "gaussian",
+		fn:         func(x float64) float64 { return math.Exp(-x * x) },
+		derivative: func(x, y float64) float64 { return -2 * x * y },
+	},
+	Sine: {
+		name:       "sine",
+		fn:         math.Sin,
+		derivative: func(x, _ float64) float64 { return math.Cos(x) },
+	},
+	Clamped: {
+		name: "clamped",
+		fn:   func(x float64) float64 { return math.Max(-1, math.Min(1, x)) },
+		derivative: func(x, _ float64) float64 {
+			if x > -1 && x < 1 {
+				return 1
+			}
+			return 0
+		},
+	},
+}
+
+// ErrUnknownActivation is returned when an activation can't be found.
+var ErrUnknownActivation = errors.New("unknown activation")
+
+// Apply returns the result of the activation function for x. It panics if
+// the activation is not valid.
+func (a Activation) Apply(x float64) float64 {
+	return activations[a].fn(x)
+}
+
+// derivative returns the derivative of the activation at x, where y is the
+// result of the activation for x.
+func (a Activation) derivative(x, y float64) float64 {
+	return activations[a].derivative(x, y)
+}
+
+// Valid returns true if the activation exists in the registry.
+func (a Activation) Valid() bool {
+	return int(a) < len(activations)
+}
+
+func (a Activation) String() string {
+	if !a.Valid() {
+		return fmt.Sprintf("Activation(%d)", a)
+	}
+	return activations[a].name
+}
+
+// ParseActivation returns the activation with the given name.
+func ParseActivation(name string) (Activation, error) {
+	for i := range activations {
+		if activations[i].name == name {
+			return Activation(i), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnknownActivation, name)
+}
+
+// Activations returns all the available activations.
+func Activations() []Activation {
+	ret := make([]Activation, len(activations))
+	for i := range ret {
+		ret[i] = Activation(i)
+	}
+	return ret
+}
@@ -0,0 +1,131 @@
+package brain
+
+import (
+	"fmt"
+	"math"
+	stdrand "math/rand"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestActivation(t *testing.T) {
+	t.Parallel()
+	t.Run("Apply", testActivationApply)
+	t.Run("Derivative", testActivationDerivative)
+	t.Run("Parse", testActivationParse)
+	t.Run("NEATPredict", testActivationNEATPredict)
+	t.Run("NEATMutate", testActivationNEATMutate)
+}
+
+func testActivationApply(t *testing.T) {
+	t.Parallel()
+	tcs := []struct {
+		activation Activation
+		input      float64
+		want       float64
+	}{
+		{Identity, -2.5, -2.5},
+		{Identity, 3, 3},
+		{Sigmoid, 0, 0.5},
+		{Sigmoid, 2, 0.880797},
+		{Tanh, 0, 0},
+		{Tanh, -1, -0.761594},
+		{ReLU, -2, 0},
+		{ReLU, 2, 2},
+		{LeakyReLU, -2, -0.02},
+		{LeakyReLU, 2, 2},
+		{Step, -0.1, 0},
+		{Step, 0, 0},
+		{Step, 0.1, 1},
+		{Gaussian, 0, 1},
+		{Gaussian, 1, 0.367879},
+		{Sine, math.Pi / 2, 1},
+		{Sine, 0, 0},
+		{Clamped, -3, -1},
+		{Clamped, 0.3, 0.3},
+		{Clamped, 3, 1},
+	}
+	for _, tc := range tcs {
+		tc := tc
+		name := fmt.Sprintf("%s(%f)", tc.activation, tc.input)
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got := tc.activation.Apply(tc.input)
+			assert.True(t, math.Abs(got-tc.want) < 1e-6, "got %f, want %f", got, tc.want)
+		})
+	}
+}
+
+func testActivationDerivative(t *testing.T) {
+	t.Parallel()
+	// The points are away from the kinks of the piecewise activations.
+	points := []float64{-2.3, -0.7, 0.4, 0.9, 1.6}
+	const h = 1e-6
+	for _, a := range Activations() {
+		for _, x := range points {
+			want := (a.Apply(x+h) - a.Apply(x-h)) / (2 * h)
+			got := a.derivative(x, a.Apply(x))
+			assert.True(t, math.Abs(want-got) < 1e-6, "%s'(%f): want %f, got %f", a, x, want, got)
+		}
+	}
+}
+
+func testActivationParse(t *testing.T) {
+	t.Parallel()
+	for _, a := range Activations() {
+		assert.True(t, a.Valid())
+		got, err := ParseActivation(a.String())
+		assert.NoError(t, err)
+		assert.Equal(t, a, got)
+	}
+
+	invalid := Activation(len(activations))
+	assert.False(t, invalid.Valid())
+	_, err := ParseActivation(invalid.String())
+	assert.IsError(t, err, ErrUnknownActivation)
+}
+
+func testActivationNEATPredict(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	neat := NewNEAT(2, 3, 0, r)
+	input := []float64{20, -30}
+	linear, err := neat.Predict(input)
+	assert.NoError(t, err)
+
+	neat.SetOutputActivation(Tanh)
+	bounded, err := neat.Predict(input)
+	assert.NoError(t, err)
+	for i := range linear {
+		assert.Equal(t, math.Tanh(linear[i]), bounded[i])
+	}
+
+	neat.nodes[2].Activation = ReLU
+	got, err := neat.Predict(input)
+	assert.NoError(t, err)
+	assert.Equal(t, math.Max(0, linear[0]), got[0])
+	assert.Equal(t, bounded[1:], got[1:])
+
+	clone := neat.Clone()
+	assert.Equal(t, ReLU, clone.nodes[2].Activation)
+	assert.Equal(t, Tanh, clone.nodes[3].Activation)
+}
+
+func testActivationNEATMutate(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(2))
+	neat := NewNEAT(2, 3, 0, r)
+	seen := make(map[Activation]bool)
+	for i := 0; i < 200; i++ {
+		neat.changeRandomActivation()
+		for _, node := range neat.nodes {
+			if node.NodeType == InputNode {
+				assert.Equal(t, Identity, node.Activation)
+				continue
+			}
+			seen[node.Activation] = true
+		}
+	}
+	assert.Equal(t, len(activations), len(seen))
+}
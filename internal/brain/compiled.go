@@ -0,0 +1,138 @@
+package brain
+
+import "fmt"
+
+// step is the calculation of a single node in a compiled network.
+type step struct {
+	bias       float64
+	slot       int32
+	start      int32
+	end        int32
+	activation Activation
+}
+
+// Compiled is a flat evaluation plan of a NEAT network. The nodes are sorted
+// in the order they should be calculated, and the connections are stored in
+// contiguous slices, therefore the prediction doesn't need to walk the graph
+// or allocate any memory. The output is identical to the NEAT's Predict
+// method.
+//
+// A Compiled is a snapshot of the network, therefore it should be compiled
+// again after the network is mutated. It holds the buffers for the
+// prediction, so it is not safe to use it concurrently.
+type Compiled struct {
+	// buf holds the values of all nodes in the first half, and their values
+	// from the previous prediction in the second half. The back edges of a
+	// recurrent network read from the second half.
+	buf       []float64
+	steps     []step
+	sources   []int32
+	weights   []float64
+	outputs   []int32
+	inputs    int
+	nodes     int
+	recurrent bool
+}
+
+// Compile returns a compiled evaluation plan of the network.
+func (n *NEAT) Compile() *Compiled {
+	slots := make(map[*Node]int32, len(n.nodes))
+	for i, node := range n.nodes {
+		slots[node] = int32(i)
+	}
+	size := len(n.nodes)
+	c := &Compiled{
+		buf:       make([]float64, size*2),
+		steps:     make([]step, 0, size),
+		outputs:   make([]int32, 0, n.outputs),
+		inputs:    n.inputs,
+		nodes:     size,
+		recurrent: n.recurrent,
+	}
+
+	// We follow the same path as the calculate function, so the sums are
+	// added up in the same order and the results are identical.
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]uint8, size)
+	for i, node := range n.nodes {
+		if node.NodeType == InputNode || len(node.incomming) == 0 {
+			state[i] = done
+		}
+	}
+	var visit func(node *Node)
+	visit = func(node *Node) {
+		slot := slots[node]
+		if state[slot] != unvisited {
+			return
+		}
+		state[slot] = visiting
+		sources := make([]int32, 0, len(node.incomming))
+		weights := make([]float64, 0, len(node.incomming))
+		for _, conn := range node.incomming {
+			if !conn.enabled {
+				continue
+			}
+			src := slots[conn.inNode]
+			if state[src] == visiting {
+				src += int32(size)
+			} else {
+				visit(conn.inNode)
+			}
+			sources = append(sources, src)
+			weights = append(weights, conn.weight)
+		}
+		state[slot] = done
+		c.steps = append(c.steps, step{
+			bias:       node.Bias,
+			slot:       slot,
+			start:      int32(len(c.sources)),
+			end:        int32(len(c.sources) + len(sources)),
+			activation: node.Activation,
+		})
+		c.sources = append(c.sources, sources...)
+		c.weights = append(c.weights, weights...)
+	}
+	for _, node := range n.nodes {
+		if node.NodeType == OutputNode {
+			visit(node)
+			c.outputs = append(c.outputs, slots[node])
+		}
+	}
+	return c
+}
+
+// Predict writes the output of the network for the given input into dst.
+func (c *Compiled) Predict(dst, input []float64) error {
+	if len(input) != c.inputs {
+		return fmt.Errorf("wrong input neurons, want %d got %d", c.inputs, len(input))
+	}
+	if len(dst) != len(c.outputs) {
+		return fmt.Errorf("wrong output neurons, want %d got %d", len(c.outputs), len(dst))
+	}
+	// The input nodes are always the first nodes of the network.
+	copy(c.buf, input)
+	for _, s := range c.steps {
+		val := s.bias
+		for i := s.start; i < s.end; i++ {
+			val += c.buf[c.sources[i]] * c.weights[i]
+		}
+		c.buf[s.slot] = s.activation.Apply(val)
+	}
+	for i, slot := range c.outputs {
+		dst[i] = c.buf[slot]
+	}
+	if c.recurrent {
+		copy(c.buf[c.nodes:], c.buf[:c.nodes])
+	}
+	return nil
+}
+
+// Reset clears the remembered values of the nodes from the previous
+// predictions.
+func (c *Compiled) Reset() {
+	clear(c.buf[c.nodes:])
+}
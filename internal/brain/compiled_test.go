@@ -0,0 +1,96 @@
+package brain
+
+import (
+	stdrand "math/rand"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestCompiled(t *testing.T) {
+	t.Parallel()
+	t.Run("FeedForward", testCompiledFeedForward)
+	t.Run("Recurrent", testCompiledRecurrent)
+	t.Run("Errors", testCompiledErrors)
+}
+
+// assertSamePredictions checks that the compiled network produces exactly the
+// same outputs as the network for a series of random inputs.
+func assertSamePredictions(t *testing.T, n *NEAT, r *stdrand.Rand) {
+	t.Helper()
+	c := n.Compile()
+	input := make([]float64, n.inputs)
+	got := make([]float64, n.outputs)
+	for i := 0; i < 5; i++ {
+		for j := range input {
+			input[j] = r.Float64()*4 - 2
+		}
+		want, err := n.Predict(input)
+		assert.NoError(t, err)
+		err = c.Predict(got, input)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+}
+
+func testCompiledFeedForward(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	n := NewNEAT(6, 3, 40, r)
+	for i := 0; i < 300; i++ {
+		n.Mutate()
+		assertSamePredictions(t, n, r)
+	}
+}
+
+func testCompiledRecurrent(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(2))
+	n := NewNEAT(6, 3, 40, r)
+	n.SetRecurrent(true)
+	for i := 0; i < 300; i++ {
+		n.Mutate()
+		n.Reset()
+		assertSamePredictions(t, n, r)
+	}
+
+	acc := accumulator(r)
+	c := acc.Compile()
+	dst := make([]float64, 1)
+	for _, want := range []float64{1, 2, 3} {
+		err := c.Predict(dst, []float64{1})
+		assert.NoError(t, err)
+		assert.Equal(t, want, dst[0])
+	}
+	c.Reset()
+	err := c.Predict(dst, []float64{1})
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, dst[0])
+}
+
+func testCompiledErrors(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(3))
+	c := NewNEAT(3, 2, 0, r).Compile()
+	err := c.Predict(make([]float64, 2), make([]float64, 2))
+	assert.Error(t, err)
+	err = c.Predict(make([]float64, 3), make([]float64, 3))
+	assert.Error(t, err)
+}
+
+// TestCompiledAllocations can't run in parallel with other tests, otherwise
+// AllocsPerRun panics.
+func TestCompiledAllocations(t *testing.T) {
+	r := stdrand.New(stdrand.NewSource(4))
+	n := NewNEAT(6, 3, 40, r)
+	for i := 0; i < 100; i++ {
+		n.Mutate()
+	}
+	c := n.Compile()
+	input := []float64{1, 2, 3, 4, 5, 6}
+	dst := make([]float64, 3)
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = c.Predict(dst, input)
+	})
+	assert.Equal(t, 0.0, allocs)
+}
@@ -0,0 +1,233 @@
+package brain
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	stdrand "math/rand"
+	"slices"
+)
+
+// ErrNotLayered is returned when a NEAT network can't be lowered into the
+// dense layers of a Network.
+var ErrNotLayered = errors.New("network is not layered")
+
+// ToNetwork lowers the network into a dense Network with the same outputs. It
+// works when the nodes can be arranged in layers: every enabled connection
+// goes from one layer to the next one, all the output nodes end up in the
+// last layer, and all the nodes of each layer have the same activation. A
+// fully connected network, as created by the NewNEAT function, always has a
+// single layer. The hidden nodes that don't affect the outputs are left out.
+// It returns an ErrNotLayered error if the topology doesn't allow it.
+//
+// The hidden layers are ordered by the node IDs, and the outputs keep their
+// order in the network.
+func (n *NEAT) ToNetwork() (*Network, error) {
+	// zero returns true for the nodes that always produce zero, because they
+	// have no incomming connections. Their connections have no effect.
+	zero := func(node *Node) bool {
+		return node.NodeType != InputNode && len(node.incomming) == 0
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[*Node]uint8, len(n.nodes))
+	depth := make(map[*Node]int, len(n.nodes))
+	var visit func(node *Node) error
+	visit = func(node *Node) error {
+		switch state[node] {
+		case visiting:
+			return fmt.Errorf("%w: node %d is in a cycle", ErrNotLayered, node.ID)
+		case done:
+			return nil
+		}
+		state[node] = visiting
+		d := 1
+		if node.NodeType == InputNode {
+			d = 0
+		}
+		for _, conn := range node.incomming {
+			if !conn.enabled || zero(conn.inNode) {
+				continue
+			}
+			if err := visit(conn.inNode); err != nil {
+				return err
+			}
+			d = max(d, depth[conn.inNode]+1)
+		}
+		state[node] = done
+		depth[node] = d
+		return nil
+	}
+
+	var inputs, outputs []*Node
+	last := 1
+	for _, node := range n.nodes {
+		switch node.NodeType {
+		case InputNode:
+			inputs = append(inputs, node)
+		case OutputNode:
+			outputs = append(outputs, node)
+			if zero(node) {
+				continue
+			}
+			if err := visit(node); err != nil {
+				return nil, err
+			}
+			last = max(last, depth[node])
+		}
+	}
+
+	// The layers don't include the inputs, therefore the nodes with the
+	// depth of d are placed in the layer d-1.
+	layerNodes := make([][]*Node, last)
+	for node, d := range depth {
+		if node.NodeType == HiddenNode {
+			if d >= last {
+				return nil, fmt.Errorf("%w: hidden node %d is not before the outputs", ErrNotLayered, node.ID)
+			}
+			layerNodes[d-1] = append(layerNodes[d-1], node)
+		}
+	}
+	for i := range layerNodes[:last-1] {
+		slices.SortFunc(layerNodes[i], func(a, b *Node) int {
+			return cmp.Compare(a.ID, b.ID)
+		})
+	}
+	layerNodes[last-1] = outputs
+
+	index := make(map[*Node]int, len(n.nodes))
+	for i, node := range inputs {
+		index[node] = i
+	}
+	layers := make([]Layer, last)
+	prev := len(inputs)
+	for i, nodes := range layerNodes {
+		activation, err := layerActivation(nodes, zero)
+		if err != nil {
+			return nil, fmt.Errorf("layer %d: %w", i, err)
+		}
+		l := Layer{
+			Weights:    make([]float64, prev*len(nodes)),
+			Biases:     make([]float64, len(nodes)),
+			Activation: activation,
+		}
+		for j, node := range nodes {
+			if zero(node) {
+				continue
+			}
+			if depth[node] != i+1 {
+				return nil, fmt.Errorf("%w: output node %d is not in the last layer", ErrNotLayered, node.ID)
+			}
+			l.Biases[j] = node.Bias
+			for _, conn := range node.incomming {
+				if !conn.enabled || zero(conn.inNode) {
+					continue
+				}
+				if depth[conn.inNode] != i {
+					return nil, fmt.Errorf("%w: connection %d skips a layer", ErrNotLayered, conn.innovation)
+				}
+				l.Weights[index[conn.inNode]*len(nodes)+j] += conn.weight
+			}
+		}
+		for j, node := range nodes {
+			index[node] = j
+		}
+		layers[i] = l
+		prev = len(nodes)
+	}
+	return New(&Config{
+		InputNeurons: len(inputs),
+		Layers:       layers,
+	})
+}
+
+// layerActivation returns the shared activation of the nodes of a layer. The
+// zero nodes have no effect on the activation, but the activation should keep
+// them at zero.
+func layerActivation(nodes []*Node, zero func(*Node) bool) (Activation, error) {
+	activation := Identity
+	idx := slices.IndexFunc(nodes, func(node *Node) bool { return !zero(node) })
+	if idx >= 0 {
+		activation = nodes[idx].Activation
+	}
+	for _, node := range nodes {
+		if zero(node) {
+			if activation.Apply(0) != 0 {
+				return 0, fmt.Errorf("%w: unconnected node %d can't be zero with %s", ErrNotLayered, node.ID, activation)
+			}
+			continue
+		}
+		if node.Activation != activation {
+			return 0, fmt.Errorf("%w: node %d has %s activation, want %s", ErrNotLayered, node.ID, node.Activation, activation)
+		}
+	}
+	return activation, nil
+}
+
+// NewNEATFromNetwork creates a fully connected NEAT network with the same
+// layers, weights, biases and activations as the given Network. The input
+// and output nodes receive the same IDs as the NewNEATWithInnovations
+// function gives them. The hidden neurons receive the same IDs in all the
+// networks that are converted with the same registry in the same generation,
+// therefore the networks with the same shape can be bred with each other.
+// This can be used for seeding a population with trained networks.
+func NewNEATFromNetwork(nn *Network, mutationRate int, rand *stdrand.Rand, innovations *Innovations) *NEAT {
+	inputs := nn.inputNeurons
+	outputs := nn.Outputs()
+	innovations.reserve(inputs+outputs, 0)
+	n := &NEAT{
+		nodes:        make([]*Node, inputs+outputs),
+		inputs:       inputs,
+		outputs:      outputs,
+		mutationRate: mutationRate,
+		rand:         rand,
+		innovations:  innovations,
+	}
+
+	prev := make([]*Node, inputs)
+	for i := range prev {
+		prev[i] = &Node{
+			NodeType: InputNode,
+			ID:       i + 1,
+		}
+		n.nodes[i] = prev[i]
+	}
+	for i := range nn.layers {
+		l := &nn.layers[i]
+		weights := l.weights.RawMatrix()
+		biases := l.biases.RawRowView(0)
+		current := make([]*Node, weights.Cols)
+		for j := range current {
+			node := &Node{
+				NodeType:   HiddenNode,
+				Bias:       biases[j],
+				Activation: l.activation,
+			}
+			if i == len(nn.layers)-1 {
+				node.NodeType = OutputNode
+				node.ID = inputs + j + 1
+				n.nodes[inputs+j] = node
+			} else {
+				node.ID = innovations.denseNode(i, j)
+				n.nodes = append(n.nodes, node)
+			}
+			node.incomming = make([]*Connection, len(prev))
+			for r, in := range prev {
+				node.incomming[r] = &Connection{
+					inNode:     in,
+					outNode:    node,
+					weight:     weights.Data[r*weights.Stride+j],
+					enabled:    true,
+					innovation: innovations.connection(in.ID, node.ID),
+				}
+			}
+			current[j] = node
+		}
+		prev = current
+	}
+	return n
+}
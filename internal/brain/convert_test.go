@@ -0,0 +1,153 @@
+package brain
+
+import (
+	"errors"
+	stdrand "math/rand"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+	t.Run("FullyConnected", testConvertFullyConnected)
+	t.Run("RoundTrip", testConvertRoundTrip)
+	t.Run("Layered", testConvertLayered)
+	t.Run("NotLayered", testConvertNotLayered)
+	t.Run("Seeding", testConvertSeeding)
+}
+
+// assertSameAsNetwork checks that the NEAT and the Network produce the same
+// outputs for random inputs.
+func assertSameAsNetwork(t *testing.T, r *stdrand.Rand, n *NEAT, nn *Network) {
+	t.Helper()
+	assert.Equal(t, n.inputs, nn.Inputs())
+	assert.Equal(t, n.outputs, nn.Outputs())
+	input := make([]float64, n.inputs)
+	for i := 0; i < 10; i++ {
+		for j := range input {
+			input[j] = r.Float64()*2 - 1
+		}
+		want, err := n.Predict(input)
+		assert.NoError(t, err)
+		got, err := nn.Predict(input)
+		assert.NoError(t, err)
+		if !almostEqual(want, got, 1e-9) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func testConvertFullyConnected(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	n := NewNEAT(5, 3, 10, r)
+	n.SetOutputActivation(Tanh)
+	for _, node := range n.nodes {
+		if node.NodeType == OutputNode {
+			node.Bias = r.Float64() - 0.5
+		}
+	}
+	nn, err := n.ToNetwork()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(nn.layers))
+	assertSameAsNetwork(t, r, n, nn)
+}
+
+func testConvertRoundTrip(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	nn := randomNetwork(t, r, 4, []int{6, 5, 2}, []Activation{ReLU, Tanh, Sigmoid})
+	n := NewNEATFromNetwork(nn, 10, r, NewInnovations())
+	assert.Equal(t, 4+6+5+2, len(n.nodes))
+	assert.Equal(t, 4*6+6*5+5*2, len(n.connections()))
+	assertSameAsNetwork(t, r, n, nn)
+
+	compiled := n.Compile()
+	want, err := nn.Predict([]float64{0.1, 0.2, 0.3, 0.4})
+	assert.NoError(t, err)
+	got := make([]float64, 2)
+	assert.NoError(t, compiled.Predict(got, []float64{0.1, 0.2, 0.3, 0.4}))
+	if !almostEqual(want, got, 1e-9) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	back, err := n.ToNetwork()
+	assert.NoError(t, err)
+	if !almostEqual(flatten(nn), flatten(back), 0) {
+		t.Fatalf("got %v, want %v", flatten(back), flatten(nn))
+	}
+	for i := range nn.layers {
+		assert.Equal(t, nn.layers[i].activation, back.layers[i].activation)
+	}
+}
+
+func testConvertLayered(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	nn := randomNetwork(t, r, 3, []int{4, 2}, []Activation{Tanh, Sigmoid})
+	n := NewNEATFromNetwork(nn, 10, r, NewInnovations())
+
+	// Sparse layers are allowed, and the dead ends are left out.
+	hidden := filter(n.nodes, func(node *Node) bool { return node.NodeType == HiddenNode })
+	hidden[0].incomming[1].enabled = false
+	output := filter(n.nodes, func(node *Node) bool { return node.NodeType == OutputNode })[1]
+	output.incomming = output.incomming[1:]
+	n.nodes = append(n.nodes, &Node{NodeType: HiddenNode, ID: 100, Activation: Sine})
+	deadEnd := &Node{NodeType: HiddenNode, ID: 101, Activation: Step}
+	deadEnd.incomming = []*Connection{{inNode: n.nodes[0], outNode: deadEnd, weight: 1, enabled: true}}
+	n.nodes = append(n.nodes, deadEnd)
+
+	got, err := n.ToNetwork()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(got.layers))
+	assertSameAsNetwork(t, r, n, got)
+}
+
+func testConvertNotLayered(t *testing.T) {
+	t.Parallel()
+	tcs := map[string]func(n *NEAT){
+		"skip connection": func(n *NEAT) {
+			n.splitRandomConnection()
+		},
+		"mixed activations": func(n *NEAT) {
+			n.nodes[len(n.nodes)-1].Activation = ReLU
+		},
+		"cycle": func(n *NEAT) {
+			n.SetRecurrent(true)
+			out := n.nodes[len(n.nodes)-1]
+			out.incomming = append(out.incomming, &Connection{inNode: out, outNode: out, weight: 1, enabled: true})
+		},
+		"zero output": func(n *NEAT) {
+			n.nodes[len(n.nodes)-1].incomming = nil
+		},
+	}
+	for name, fn := range tcs {
+		r := stdrand.New(stdrand.NewSource(1))
+		n := NewNEAT(3, 2, 10, r)
+		n.SetOutputActivation(Sigmoid)
+		fn(n)
+		_, err := n.ToNetwork()
+		assert.True(t, errors.Is(err, ErrNotLayered), "%s: %v", name, err)
+	}
+}
+
+func testConvertSeeding(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	registry := NewInnovations()
+	a := NewNEATFromNetwork(randomNetwork(t, r, 3, []int{4, 2}, []Activation{Tanh, Sigmoid}), 10, r, registry)
+	b := NewNEATFromNetwork(randomNetwork(t, r, 3, []int{4, 2}, []Activation{Tanh, Sigmoid}), 10, r, registry)
+	assert.Equal(t, innovations(a), innovations(b))
+	config := SpeciesConfig{ExcessCoefficient: 1, DisjointCoefficient: 1}
+	assert.Equal(t, 0.0, config.Distance(a, b))
+
+	// New mutations don't collide with the converted nodes.
+	c := NewNEATWithInnovations(3, 2, 10, r, registry)
+	c.addRandomNode()
+	for _, node := range a.nodes {
+		assert.NotEqual(t, node.ID, c.nodes[len(c.nodes)-1].ID)
+	}
+	child := Crossover(a, b)
+	assert.Equal(t, len(a.connections()), len(child.connections()))
+}
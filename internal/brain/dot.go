@@ -0,0 +1,91 @@
+package brain
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Colours and widths of the DOT graph.
+const (
+	dotPositive    = "#1a9641"
+	dotNegative    = "#d7191c"
+	dotInput       = "#abd9e9"
+	dotHidden      = "#e0e0e0"
+	dotOutput      = "#fdae61"
+	dotMinPenwidth = 0.5
+	dotMaxPenwidth = 4.0
+)
+
+// WriteDOT writes the network as a Graphviz DOT graph to w. The input nodes
+// are placed in the first rank and the output nodes in the last one. The
+// connections are coloured by the sign of their weights, their thickness is
+// relative to the largest absolute weight in the network, and the disabled
+// connections are dashed. The output is stable for the same network, so it
+// can be kept under version control.
+func (n *NEAT) WriteDOT(w io.Writer) error {
+	conns := n.connections()
+	maxWeight := 0.0
+	for _, c := range conns {
+		maxWeight = math.Max(maxWeight, math.Abs(c.weight))
+	}
+
+	b := &strings.Builder{}
+	b.WriteString("digraph neat {\n")
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tsplines=true;\n")
+	b.WriteString("\tnode [shape=circle, style=filled, fontname=\"Helvetica\", fontsize=10];\n")
+	b.WriteString("\tedge [arrowsize=0.5];\n")
+
+	ranks := []struct {
+		name   string
+		rank   string
+		colour string
+		kind   NodeType
+	}{
+		{name: "inputs", rank: "source", colour: dotInput, kind: InputNode},
+		{name: "hidden", colour: dotHidden, kind: HiddenNode},
+		{name: "outputs", rank: "sink", colour: dotOutput, kind: OutputNode},
+	}
+	for _, r := range ranks {
+		fmt.Fprintf(b, "\tsubgraph %s {\n", r.name)
+		if r.rank != "" {
+			fmt.Fprintf(b, "\t\trank=%s;\n", r.rank)
+		}
+		for _, node := range n.nodes {
+			if node.NodeType != r.kind {
+				continue
+			}
+			label := fmt.Sprintf("%d", node.ID)
+			if node.NodeType != InputNode {
+				label = fmt.Sprintf("%d\\n%s\\n%+.2f", node.ID, node.Activation, node.Bias)
+			}
+			fmt.Fprintf(b, "\t\tn%d [label=\"%s\", fillcolor=%q];\n", node.ID, label, r.colour)
+		}
+		b.WriteString("\t}\n")
+	}
+
+	for _, c := range conns {
+		colour := dotPositive
+		if c.weight < 0 {
+			colour = dotNegative
+		}
+		width := dotMinPenwidth
+		if maxWeight > 0 {
+			width += (dotMaxPenwidth - dotMinPenwidth) * math.Abs(c.weight) / maxWeight
+		}
+		style := "solid"
+		if !c.enabled {
+			style = "dashed"
+		}
+		fmt.Fprintf(b, "\tn%d -> n%d [color=%q, penwidth=%.2f, style=%s, tooltip=\"#%d %+.3f\"];\n",
+			c.inNode.ID, c.outNode.ID, colour, width, style, c.innovation, c.weight)
+	}
+	b.WriteString("}\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("writing DOT graph: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package brain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	stdrand "math/rand"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestWriteDOT(t *testing.T) {
+	t.Parallel()
+	t.Run("Graph", testWriteDOTGraph)
+	t.Run("Stable", testWriteDOTStable)
+	t.Run("WriteError", testWriteDOTWriteError)
+}
+
+func testWriteDOTGraph(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	n := NewNEAT(2, 1, 0, r)
+	n.nodes[2].incomming[0].weight = -0.5
+	n.nodes[2].incomming[1].weight = 0.3
+	n.splitRandomConnection()
+
+	buf := &bytes.Buffer{}
+	err := n.WriteDOT(buf)
+	assert.NoError(t, err)
+	got := buf.String()
+
+	assert.True(t, strings.HasPrefix(got, "digraph neat {\n"))
+	assert.True(t, strings.HasSuffix(got, "}\n"))
+	assert.Contains(t, got, "subgraph inputs {\n\t\trank=source;\n\t\tn1 ")
+	assert.Contains(t, got, "subgraph outputs {\n\t\trank=sink;\n\t\tn3 ")
+	assert.Contains(t, got, "subgraph hidden {\n\t\tn4 [label=\"4\\nidentity\\n+0.00\"")
+
+	conns := n.connections()
+	assert.Equal(t, 4, len(conns))
+	for _, c := range conns {
+		line := edgeLine(t, got, c)
+		if c.enabled {
+			assert.Contains(t, line, "style=solid")
+		} else {
+			assert.Contains(t, line, "style=dashed")
+		}
+		if c.weight < 0 {
+			assert.Contains(t, line, dotNegative)
+		} else {
+			assert.Contains(t, line, dotPositive)
+		}
+	}
+
+	// The split connection has the weight of 1, therefore it is the widest.
+	widths := map[float64]string{1: "penwidth=4.00", 0.5: "penwidth=2.25", 0.3: "penwidth=1.55"}
+	for _, c := range conns {
+		assert.Contains(t, edgeLine(t, got, c), widths[math.Abs(c.weight)])
+	}
+}
+
+// edgeLine returns the line of the DOT graph that draws the connection.
+func edgeLine(t *testing.T, graph string, c *Connection) string {
+	t.Helper()
+	assert.NotZero(t, c)
+	prefix := fmt.Sprintf("\tn%d -> n%d ", c.inNode.ID, c.outNode.ID)
+	for _, line := range strings.Split(graph, "\n") {
+		if strings.HasPrefix(line, prefix) {
+			return line
+		}
+	}
+	t.Fatalf("no edge for %s", c)
+	return ""
+}
+
+func testWriteDOTStable(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(2))
+	n := NewNEAT(3, 2, 40, r)
+	n.SetRecurrent(true)
+	for i := 0; i < 100; i++ {
+		n.Mutate()
+	}
+	a := &bytes.Buffer{}
+	err := n.WriteDOT(a)
+	assert.NoError(t, err)
+	b := &bytes.Buffer{}
+	err = n.Clone().WriteDOT(b)
+	assert.NoError(t, err)
+	assert.Equal(t, a.String(), b.String())
+	assert.Equal(t, len(n.connections()), strings.Count(a.String(), " -> "))
+}
+
+func testWriteDOTWriteError(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(3))
+	n := NewNEAT(2, 1, 0, r)
+	err := n.WriteDOT(errWriter{})
+	assert.IsError(t, err, errWrite)
+}
+
+var errWrite = errors.New("write failed")
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) { return 0, errWrite }
@@ -0,0 +1,154 @@
+package brain
+
+import (
+	"fmt"
+	"math"
+	stdrand "math/rand"
+
+	"github.com/arsham/neuragene/internal/geom"
+)
+
+const (
+	// CPPNInputs is the number of the inputs of a CPPN. They are the
+	// coordinates of the source neuron, the coordinates of the target neuron,
+	// and a constant bias of 1.
+	CPPNInputs = 5
+	// CPPNOutputs is the number of the outputs of a CPPN. The first output is
+	// the weight of the connection, and the second one is the bias of the
+	// target neuron.
+	CPPNOutputs = 2
+)
+
+// NewCPPN creates a NEAT network that can be used as a CPPN for a Substrate.
+// The outputs use the Tanh activation, so they stay in the [-1, 1] range. A
+// population of CPPNs can be created with CPPNInputs and CPPNOutputs as the
+// inputs and outputs of the population.
+func NewCPPN(mutationRate int, rand *stdrand.Rand, innovations *Innovations) *NEAT {
+	n := NewNEATWithInnovations(CPPNInputs, CPPNOutputs, mutationRate, rand, innovations)
+	n.SetOutputActivation(Tanh)
+	return n
+}
+
+// Substrate is the geometry of a dense network for HyperNEAT. Each neuron has
+// a position in a 2D space, and an evolved NEAT network, called a CPPN,
+// produces the weight of each connection from the positions of its neurons.
+// The size of the CPPN is independent of the size of the network, therefore
+// networks with many inputs, like ant vision with dozens of rays, can be
+// evolved with small genomes.
+type Substrate struct {
+	// Layers are the positions of the neurons of each layer, starting with
+	// the inputs. The coordinates are usually in the [-1, 1] range.
+	Layers [][]geom.Vec
+	// Activations are the activations of each layer besides the input layer.
+	Activations []Activation
+	// Threshold is the minimum magnitude of a CPPN output for the connection
+	// to be expressed. The weaker connections receive zero weight. It should
+	// be in the [0, 1) range.
+	Threshold float64
+	// MaxWeight is the magnitude of the weights of the CPPN outputs of 1 or
+	// -1. The default value is 3.
+	MaxWeight float64
+}
+
+// Row returns the positions of count neurons that are evenly spread on a
+// horizontal line at the given y, between -1 and 1. It is useful for
+// describing the layers of a substrate.
+func Row(count int, y float64) []geom.Vec {
+	ret := make([]geom.Vec, count)
+	for i := range ret {
+		x := 0.0
+		if count > 1 {
+			x = -1 + 2*float64(i)/float64(count-1)
+		}
+		ret[i] = geom.V(x, y)
+	}
+	return ret
+}
+
+// validate returns an error if the substrate or the CPPN can't build a
+// network.
+func (s *Substrate) validate(cppn *NEAT) error {
+	if cppn.inputs != CPPNInputs || cppn.outputs != CPPNOutputs {
+		return fmt.Errorf("%w: cppn has %d inputs and %d outputs, want %d and %d",
+			ErrInvalidConfig, cppn.inputs, cppn.outputs, CPPNInputs, CPPNOutputs)
+	}
+	if len(s.Layers) < 2 {
+		return fmt.Errorf("%w: substrate has %d layers", ErrInvalidConfig, len(s.Layers))
+	}
+	if len(s.Activations) != len(s.Layers)-1 {
+		return fmt.Errorf("%w: %d activations for %d layers", ErrInvalidConfig, len(s.Activations), len(s.Layers)-1)
+	}
+	for i, a := range s.Activations {
+		if !a.Valid() {
+			return fmt.Errorf("layer %d: %w: %s", i, ErrUnknownActivation, a)
+		}
+	}
+	for i, l := range s.Layers {
+		if len(l) == 0 {
+			return fmt.Errorf("%w: substrate layer %d has no neurons", ErrInvalidConfig, i)
+		}
+	}
+	if s.Threshold < 0 || s.Threshold >= 1 {
+		return fmt.Errorf("%w: threshold %f", ErrInvalidConfig, s.Threshold)
+	}
+	return nil
+}
+
+// Build queries the CPPN for the weights and biases of the substrate, and
+// returns the dense network. The weight of each connection is the first
+// output of the CPPN for the positions of its neurons. The bias of each
+// neuron is the second output of the CPPN for a connection from the origin to
+// the neuron.
+func (s *Substrate) Build(cppn *NEAT) (*Network, error) {
+	if err := s.validate(cppn); err != nil {
+		return nil, err
+	}
+	maxWeight := s.MaxWeight
+	if maxWeight == 0 {
+		maxWeight = 3
+	}
+	compiled := cppn.Compile()
+	input := make([]float64, CPPNInputs)
+	output := make([]float64, CPPNOutputs)
+	query := func(from, to geom.Vec) {
+		input[0], input[1] = from.XY()
+		input[2], input[3] = to.XY()
+		input[4] = 1
+		// Each query is independent of the previous ones.
+		compiled.Reset()
+		// The sizes always match the CPPN.
+		_ = compiled.Predict(output, input)
+	}
+	express := func(v float64) float64 {
+		v = max(-1, min(1, v))
+		if math.Abs(v) <= s.Threshold {
+			return 0
+		}
+		// The expressed values start from zero at the threshold.
+		scaled := (math.Abs(v) - s.Threshold) / (1 - s.Threshold) * maxWeight
+		return math.Copysign(scaled, v)
+	}
+
+	layers := make([]Layer, len(s.Layers)-1)
+	for i := range layers {
+		prev, current := s.Layers[i], s.Layers[i+1]
+		l := Layer{
+			Weights:    make([]float64, len(prev)*len(current)),
+			Biases:     make([]float64, len(current)),
+			Activation: s.Activations[i],
+		}
+		for j, to := range current {
+			for r, from := range prev {
+				query(from, to)
+				l.Weights[r*len(current)+j] = express(output[0])
+			}
+			query(geom.ZV, to)
+			l.Biases[j] = express(output[1])
+		}
+		layers[i] = l
+	}
+	return New(&Config{
+		InputNeurons: len(s.Layers[0]),
+		Layers:       layers,
+	})
+}
@@ -0,0 +1,125 @@
+package brain
+
+import (
+	"errors"
+	"math"
+	stdrand "math/rand"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/arsham/neuragene/internal/geom"
+)
+
+func TestSubstrate(t *testing.T) {
+	t.Parallel()
+	t.Run("Row", testSubstrateRow)
+	t.Run("Build", testSubstrateBuild)
+	t.Run("Weights", testSubstrateWeights)
+	t.Run("Errors", testSubstrateErrors)
+}
+
+func testSubstrateRow(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, []geom.Vec{geom.V(-1, 0.5), geom.V(0, 0.5), geom.V(1, 0.5)}, Row(3, 0.5))
+	assert.Equal(t, []geom.Vec{geom.V(0, -1)}, Row(1, -1))
+}
+
+func testSubstrateBuild(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	cppn := NewCPPN(10, r, NewInnovations())
+	for i := 0; i < 50; i++ {
+		cppn.Mutate()
+	}
+	s := &Substrate{
+		Layers:      [][]geom.Vec{Row(64, -1), Row(16, 0), Row(4, 1)},
+		Activations: []Activation{Tanh, Sigmoid},
+		Threshold:   0.2,
+	}
+	nn, err := s.Build(cppn)
+	assert.NoError(t, err)
+	assert.Equal(t, 64, nn.Inputs())
+	assert.Equal(t, 4, nn.Outputs())
+	assert.True(t, nn.Parameters() > 10*len(cppn.connections()), "the network is larger than its genome")
+	for _, p := range nn.parameters() {
+		for _, v := range p {
+			assert.True(t, v == 0 || math.Abs(v) <= 3, "weight %f", v)
+		}
+	}
+
+	again, err := s.Build(cppn)
+	assert.NoError(t, err)
+	assert.Equal(t, flatten(nn), flatten(again), "building is deterministic")
+}
+
+// testSubstrateWeights checks the weights of a CPPN that returns the x
+// coordinate of the target neuron as the weight.
+func testSubstrateWeights(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	cppn := NewCPPN(10, r, NewInnovations())
+	cppn.SetOutputActivation(Identity)
+	for _, node := range cppn.nodes {
+		for _, c := range node.incomming {
+			c.weight = 0
+			if c.inNode.ID == 3 && c.outNode.ID == CPPNInputs+1 {
+				c.weight = 1
+			}
+			if c.inNode.ID == 5 && c.outNode.ID == CPPNInputs+2 {
+				c.weight = 0.5
+			}
+		}
+	}
+	s := &Substrate{
+		Layers:      [][]geom.Vec{Row(2, -1), Row(3, 1)},
+		Activations: []Activation{Identity},
+		Threshold:   0.5,
+		MaxWeight:   2,
+	}
+	nn, err := s.Build(cppn)
+	assert.NoError(t, err)
+	// The targets are at -1, 0 and 1, and the weights below the threshold
+	// are not expressed.
+	assert.Equal(t, []float64{-2, 0, 2, -2, 0, 2}, nn.parameters()[0])
+	assert.Equal(t, []float64{0, 0, 0}, nn.parameters()[1])
+}
+
+func testSubstrateErrors(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	cppn := NewCPPN(10, r, NewInnovations())
+	tcs := map[string]struct {
+		substrate *Substrate
+		cppn      *NEAT
+	}{
+		"cppn shape": {
+			substrate: &Substrate{Layers: [][]geom.Vec{Row(2, 0), Row(2, 1)}, Activations: []Activation{Tanh}},
+			cppn:      NewNEAT(4, 1, 10, r),
+		},
+		"one layer": {
+			substrate: &Substrate{Layers: [][]geom.Vec{Row(2, 0)}},
+			cppn:      cppn,
+		},
+		"activations": {
+			substrate: &Substrate{Layers: [][]geom.Vec{Row(2, 0), Row(2, 1)}},
+			cppn:      cppn,
+		},
+		"empty layer": {
+			substrate: &Substrate{Layers: [][]geom.Vec{Row(2, 0), nil}, Activations: []Activation{Tanh}},
+			cppn:      cppn,
+		},
+		"threshold": {
+			substrate: &Substrate{Layers: [][]geom.Vec{Row(2, 0), Row(2, 1)}, Activations: []Activation{Tanh}, Threshold: 1},
+			cppn:      cppn,
+		},
+	}
+	for name, tc := range tcs {
+		_, err := tc.substrate.Build(tc.cppn)
+		assert.True(t, errors.Is(err, ErrInvalidConfig), "%s: %v", name, err)
+	}
+	_, err := (&Substrate{
+		Layers:      [][]geom.Vec{Row(2, 0), Row(2, 1)},
+		Activations: []Activation{Activation(200)},
+	}).Build(cppn)
+	assert.True(t, errors.Is(err, ErrUnknownActivation))
+}
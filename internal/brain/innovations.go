@@ -0,0 +1,155 @@
+package brain
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// link is a pair of node IDs that a structural mutation happens between.
+type link struct {
+	in  int
+	out int
+}
+
+// Innovations keeps track of the innovation numbers of the connections and the
+// IDs of the hidden nodes of a population. Identical structural mutations in
+// the same generation, that are mutations between the same pair of nodes,
+// receive the same numbers. This makes the crossover and speciation of
+// independently mutated networks possible. It is safe to use it concurrently.
+type Innovations struct {
+	connections    map[link]int32
+	nodes          map[link]int
+	mu             sync.Mutex
+	lastNode       int
+	lastInnovation int32
+}
+
+// NewInnovations returns a new Innovations with no recorded mutations.
+func NewInnovations() *Innovations {
+	return &Innovations{
+		connections: make(map[link]int32),
+		nodes:       make(map[link]int),
+	}
+}
+
+// connection returns the innovation number of a connection from the in node
+// to the out node. If the connection has already been created in this
+// generation, the same number is returned.
+func (i *Innovations) connection(in, out int) int32 {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	l := link{in: in, out: out}
+	if innovation, ok := i.connections[l]; ok {
+		return innovation
+	}
+	i.lastInnovation++
+	i.connections[l] = i.lastInnovation
+	return i.lastInnovation
+}
+
+// node returns the ID of a hidden node that is created between the in and out
+// nodes. If such a node has already been created in this generation, the same
+// ID is returned.
+func (i *Innovations) node(in, out int) int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	l := link{in: in, out: out}
+	if id, ok := i.nodes[l]; ok {
+		return id
+	}
+	i.lastNode++
+	i.nodes[l] = i.lastNode
+	return i.lastNode
+}
+
+// denseNode returns the ID of the hidden neuron of a dense network in the
+// given layer and position. The same neuron receives the same ID in this
+// generation. The layer is recorded as a negative in node, so it never
+// collides with the mutations between the real nodes.
+func (i *Innovations) denseNode(layer, neuron int) int {
+	return i.node(-layer-1, neuron)
+}
+
+// newNode returns a new node ID without recording it as a mutation.
+func (i *Innovations) newNode() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.lastNode++
+	return i.lastNode
+}
+
+// reserve makes sure the registry doesn't give out node IDs and innovation
+// numbers that are less than or equal to the given values.
+func (i *Innovations) reserve(node int, innovation int32) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.lastNode = max(i.lastNode, node)
+	i.lastInnovation = max(i.lastInnovation, innovation)
+}
+
+// NextGeneration forgets the mutations of the current generation. The same
+// mutation in the next generation will receive new numbers.
+func (i *Innovations) NextGeneration() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	clear(i.connections)
+	clear(i.nodes)
+}
+
+// innovationsRecord is the stored form of the Innovations.
+type innovationsRecord struct {
+	Connections    [][3]int `json:"connections"`
+	Nodes          [][3]int `json:"nodes"`
+	LastNode       int      `json:"last_node"`
+	LastInnovation int32    `json:"last_innovation"`
+}
+
+// MarshalJSON returns the JSON encoding of the Innovations, including the
+// mutations of the current generation.
+func (i *Innovations) MarshalJSON() ([]byte, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	r := innovationsRecord{
+		Connections:    make([][3]int, 0, len(i.connections)),
+		Nodes:          make([][3]int, 0, len(i.nodes)),
+		LastNode:       i.lastNode,
+		LastInnovation: i.lastInnovation,
+	}
+	for l, innovation := range i.connections {
+		r.Connections = append(r.Connections, [3]int{l.in, l.out, int(innovation)})
+	}
+	for l, id := range i.nodes {
+		r.Nodes = append(r.Nodes, [3]int{l.in, l.out, id})
+	}
+	// Maps are not ordered, so we sort them by their numbers for a stable
+	// output.
+	byNumber := func(a, b [3]int) int { return cmp.Compare(a[2], b[2]) }
+	slices.SortFunc(r.Connections, byNumber)
+	slices.SortFunc(r.Nodes, byNumber)
+	return json.Marshal(r)
+}
+
+// UnmarshalJSON restores the Innovations from the JSON encoding produced by
+// the MarshalJSON method.
+func (i *Innovations) UnmarshalJSON(data []byte) error {
+	var r innovationsRecord
+	if err := json.Unmarshal(data, &r); err != nil {
+		return fmt.Errorf("decoding innovations: %w", err)
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.lastNode = r.LastNode
+	i.lastInnovation = r.LastInnovation
+	i.connections = make(map[link]int32, len(r.Connections))
+	for _, c := range r.Connections {
+		i.connections[link{in: c[0], out: c[1]}] = int32(c[2])
+	}
+	i.nodes = make(map[link]int, len(r.Nodes))
+	for _, n := range r.Nodes {
+		i.nodes[link{in: n[0], out: n[1]}] = n[2]
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package brain
+
+import (
+	"encoding/json"
+	stdrand "math/rand"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestInnovations(t *testing.T) {
+	t.Parallel()
+	t.Run("NewNEAT", testInnovationsNewNEAT)
+	t.Run("Deduplicate", testInnovationsDeduplicate)
+	t.Run("NextGeneration", testInnovationsNextGeneration)
+	t.Run("SameMutationTwice", testInnovationsSameMutationTwice)
+	t.Run("JSON", testInnovationsJSON)
+}
+
+func testInnovationsNewNEAT(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	want := []int32{1, 2, 3, 4, 5, 6}
+	assert.Equal(t, want, innovations(NewNEAT(3, 2, 0, r)))
+	assert.Equal(t, want, innovations(NewNEAT(3, 2, 0, r)))
+
+	registry := NewInnovations()
+	a := NewNEATWithInnovations(3, 2, 0, r, registry)
+	b := NewNEATWithInnovations(3, 2, 0, r, registry)
+	assert.Equal(t, want, innovations(a))
+	assert.Equal(t, want, innovations(b))
+}
+
+func testInnovationsDeduplicate(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(2))
+	registry := NewInnovations()
+	a := NewNEATWithInnovations(1, 1, 0, r, registry)
+	b := NewNEATWithInnovations(1, 1, 0, r, registry)
+	a.splitRandomConnection()
+	b.splitRandomConnection()
+	assert.Equal(t, []int32{1, 2, 3}, innovations(a))
+	assert.Equal(t, innovations(a), innovations(b))
+	assert.Equal(t, 3, a.nodes[2].ID)
+	assert.Equal(t, a.nodes[2].ID, b.nodes[2].ID)
+}
+
+func testInnovationsNextGeneration(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(3))
+	registry := NewInnovations()
+	a := NewNEATWithInnovations(1, 1, 0, r, registry)
+	b := a.Clone()
+	a.splitRandomConnection()
+	registry.NextGeneration()
+	b.splitRandomConnection()
+	assert.Equal(t, []int32{1, 2, 3}, innovations(a))
+	assert.Equal(t, []int32{1, 4, 5}, innovations(b))
+	assert.Equal(t, 3, a.nodes[2].ID)
+	assert.Equal(t, 4, b.nodes[2].ID)
+}
+
+func testInnovationsSameMutationTwice(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(4))
+	registry := NewInnovations()
+	a := NewNEATWithInnovations(1, 1, 0, r, registry)
+	a.splitRandomConnection()
+	id := a.nodes[2].ID
+	assert.NotEqual(t, id, a.nodeID(1, 2))
+
+	b := NewNEATWithInnovations(1, 1, 0, r, registry)
+	assert.Equal(t, id, b.nodeID(1, 2))
+}
+
+func testInnovationsJSON(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(5))
+	registry := NewInnovations()
+	a := NewNEATWithInnovations(2, 1, 0, r, registry)
+	a.splitRandomConnection()
+
+	data, err := json.Marshal(registry)
+	assert.NoError(t, err)
+	again, err := json.Marshal(registry)
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), string(again))
+
+	restored := &Innovations{}
+	err = json.Unmarshal(data, restored)
+	assert.NoError(t, err)
+	assert.Equal(t, registry.connections, restored.connections)
+	assert.Equal(t, registry.nodes, restored.nodes)
+	assert.Equal(t, registry.connection(1, 3), restored.connection(1, 3))
+	assert.Equal(t, registry.connection(4, 2), restored.connection(4, 2))
+	assert.Equal(t, registry.node(2, 3), restored.node(2, 3))
+
+	err = json.Unmarshal([]byte("{"), restored)
+	assert.Error(t, err)
+}
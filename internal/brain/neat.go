@@ -2,46 +2,72 @@
 package brain
 
 import (
+	"cmp"
 	"fmt"
 	stdrand "math/rand"
 	"slices"
 	"strings"
-	"sync/atomic"
 )
 
-var lastInnovation atomic.Int32
-
 // Node represents a node in a neural network.
 type Node struct {
 	incomming []*Connection
 	NodeType  NodeType
 	Bias      float64
 	tempVal   float64
-	ID        int
-	tempSet   bool
+	// state is the value of the node in the previous prediction. It is only
+	// used in recurrent networks.
+	state      float64
+	ID         int
+	Activation Activation
+	tempSet    bool
+	// visiting is set while the node's value is being calculated, so we
+	// can detect the back edges of recurrent connections.
+	visiting bool
 }
 
 func (n *Node) String() string {
-	return fmt.Sprintf("[%s:%d] %+.3f <%+.3f:%t>", n.NodeType, n.ID, n.Bias, n.tempVal, n.tempSet)
+	return fmt.Sprintf("[%s:%d:%s] %+.3f <%+.3f:%t>", n.NodeType, n.ID, n.Activation, n.Bias, n.tempVal, n.tempSet)
 }
 
 // hasConnectionFrom returns true if the given node is connected directly or
-// indirectly to this node.
+// indirectly to this node. The incomming connections of a node always have
+// the node as their outNode, therefore we walk the graph backwards through the
+// inNodes. Each node is visited once, so the walk ends even if the network
+// has cycles.
 func (n *Node) hasConnectionFrom(node *Node) bool {
 	if node == nil {
 		return false
 	}
-	if n.ID == node.ID {
-		return true
-	}
-	for i := range n.incomming {
-		if n.incomming[i].outNode.hasConnectionFrom(node) {
+	visited := map[*Node]bool{n: true}
+	stack := []*Node{n}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if current.ID == node.ID {
 			return true
 		}
+		for _, c := range current.incomming {
+			if !visited[c.inNode] {
+				visited[c.inNode] = true
+				stack = append(stack, c.inNode)
+			}
+		}
 	}
 	return false
 }
 
+// connectionFrom returns the connection from the node with the given ID to
+// this node. It returns nil if there is no direct connection.
+func (n *Node) connectionFrom(id int) *Connection {
+	for i := range n.incomming {
+		if n.incomming[i].inNode.ID == id {
+			return n.incomming[i]
+		}
+	}
+	return nil
+}
+
 // NodeType represents the type of a node.
 type NodeType int
 
@@ -84,19 +110,36 @@ func (c *Connection) String() string {
 	return fmt.Sprintf("(%s)--[%s]-->(%s)", c.inNode, connectivity, c.outNode)
 }
 
-// NEAT implements the NEAT genetic algorithm for evolving neural networks.
+// NEAT implements the NEAT genetic algorithm for evolving neural networks. By
+// default the networks are feed-forward. When the recurrent mode is enabled,
+// the mutations can create cycles and self-loops, and the network remembers
+// the values of its nodes between predictions.
 type NEAT struct {
 	rand         *stdrand.Rand
+	innovations  *Innovations
 	nodes        []*Node
 	inputs       int
 	outputs      int
 	mutationRate int
+	recurrent    bool
 }
 
 // NewNEAT creates a new NEAT with the given number of input and output nodes.
 // The result is a fully connected network with randomly set biases and
-// weights.
+// weights. The network has its own innovation registry, therefore it should
+// not be bred with other networks created by this function. Use the
+// NewNEATWithInnovations function for creating networks of a population.
 func NewNEAT(inputs, outputs, mutationRate int, rand *stdrand.Rand) *NEAT {
+	return NewNEATWithInnovations(inputs, outputs, mutationRate, rand, NewInnovations())
+}
+
+// NewNEATWithInnovations creates a new NEAT with the given number of input and
+// output nodes, that records its structural mutations in the given innovation
+// registry. All networks created with the same registry in the same
+// generation have the same innovation numbers.
+func NewNEATWithInnovations(inputs, outputs, mutationRate int, rand *stdrand.Rand, innovations *Innovations) *NEAT {
+	// The hidden nodes should not collide with the input and output nodes.
+	innovations.reserve(inputs+outputs, 0)
 	inputNodes := make([]*Node, inputs, inputs+outputs)
 	outputNodes := make([]*Node, outputs)
 	for i := range inputNodes {
@@ -118,7 +161,7 @@ func NewNEAT(inputs, outputs, mutationRate int, rand *stdrand.Rand) *NEAT {
 				outNode:    outputNodes[j],
 				weight:     rand.Float64() - 0.5, // [-0.5, 0.5)
 				enabled:    true,
-				innovation: lastInnovation.Add(1),
+				innovation: innovations.connection(inputNodes[i].ID, outputNodes[j].ID),
 			}
 			outputNodes[j].incomming = append(outputNodes[j].incomming, connection)
 		}
@@ -130,6 +173,7 @@ func NewNEAT(inputs, outputs, mutationRate int, rand *stdrand.Rand) *NEAT {
 		outputs:      outputs,
 		mutationRate: mutationRate,
 		rand:         rand,
+		innovations:  innovations,
 	}
 }
 
@@ -156,7 +200,6 @@ func (n *NEAT) Predict(input []float64) ([]float64, error) {
 	}
 	inputNodes := filter(n.nodes, func(n *Node) bool { return n.NodeType == InputNode })
 	for i := range inputNodes {
-		inputNodes[i].Bias = input[i]
 		inputNodes[i].tempVal = input[i]
 		inputNodes[i].tempSet = true
 	}
@@ -166,9 +209,106 @@ func (n *NEAT) Predict(input []float64) ([]float64, error) {
 	for i, node := range lastNodes {
 		ret[i] = calculate(node)
 	}
+	if n.recurrent {
+		for i := range n.nodes {
+			n.nodes[i].state = n.nodes[i].tempVal
+		}
+	}
 	return ret, nil
 }
 
+// SetRecurrent enables or disables the recurrent mode. In the recurrent mode
+// the mutations are allowed to create cycles and self-loops. When predicting,
+// the connections that point back to a node that is still being calculated
+// use the value of that node from the previous prediction. Disabling the
+// recurrent mode removes the connections that create cycles, including the
+// self-loops.
+func (n *NEAT) SetRecurrent(recurrent bool) {
+	n.recurrent = recurrent
+	if !recurrent {
+		n.removeCycles()
+	}
+}
+
+// removeCycles removes the back edges of the network, that are the
+// connections that point back to a node that is still being calculated when
+// predicting. The remaining network is feed-forward.
+func (n *NEAT) removeCycles() {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[*Node]int, len(n.nodes))
+	var visit func(node *Node)
+	visit = func(node *Node) {
+		state[node] = visiting
+		node.incomming = slices.DeleteFunc(node.incomming, func(c *Connection) bool {
+			return state[c.inNode] == visiting
+		})
+		for _, c := range node.incomming {
+			if state[c.inNode] == unvisited {
+				visit(c.inNode)
+			}
+		}
+		state[node] = done
+	}
+	for _, node := range n.nodes {
+		if state[node] == unvisited {
+			visit(node)
+		}
+	}
+}
+
+// Recurrent returns true if the network is in the recurrent mode.
+func (n *NEAT) Recurrent() bool {
+	return n.recurrent
+}
+
+// Inputs returns the number of the input nodes.
+func (n *NEAT) Inputs() int {
+	return n.inputs
+}
+
+// Outputs returns the number of the output nodes.
+func (n *NEAT) Outputs() int {
+	return n.outputs
+}
+
+// Reset clears the remembered values of the nodes from the previous
+// predictions.
+func (n *NEAT) Reset() {
+	for i := range n.nodes {
+		n.nodes[i].state = 0
+	}
+}
+
+// connections returns all the connections of the network, including the
+// disabled ones, sorted by their innovation numbers.
+func (n *NEAT) connections() []*Connection {
+	ret := make([]*Connection, 0, len(n.nodes)*2)
+	for i := range n.nodes {
+		ret = append(ret, n.nodes[i].incomming...)
+	}
+	slices.SortFunc(ret, func(a, b *Connection) int {
+		return cmp.Compare(a.innovation, b.innovation)
+	})
+	return ret
+}
+
+// nodeID returns the ID of a hidden node that is created between the in and
+// out nodes. The same structural mutation results in the same node ID in all
+// the networks of the generation, which is required for aligning the genes in
+// a crossover. If the network already has a node with the ID, because the
+// same mutation has happened twice, a new ID is returned.
+func (n *NEAT) nodeID(in, out int) int {
+	id := n.innovations.node(in, out)
+	if slices.ContainsFunc(n.nodes, func(node *Node) bool { return node.ID == id }) {
+		return n.innovations.newNode()
+	}
+	return id
+}
+
 func filter(nodes []*Node, fn func(*Node) bool) []*Node {
 	ret := make([]*Node, 0, len(nodes))
 	for i := range nodes {
@@ -180,71 +320,174 @@ func filter(nodes []*Node, fn func(*Node) bool) []*Node {
 	return ret
 }
 
-func filterPick(nodes []*Node, fn func(*Node) bool) *Node {
-	nodes = filter(nodes, fn)
-	index := stdrand.Intn(len(nodes))
-	return nodes[index]
+// pick returns a random node that satisfies the fn predicate. It returns nil
+// if no nodes match.
+func (n *NEAT) pick(fn func(*Node) bool) *Node {
+	nodes := filter(n.nodes, fn)
+	if len(nodes) == 0 {
+		return nil
+	}
+	return nodes[n.rand.Intn(len(nodes))]
+}
+
+// hasIncomming returns true if the node is not an input node and has at least
+// one incomming connection.
+func hasIncomming(n *Node) bool {
+	return n.NodeType != InputNode && len(n.incomming) > 0
 }
 
 func calculate(node *Node) float64 {
 	if node.tempSet || len(node.incomming) == 0 {
 		return node.tempVal
 	}
+	if node.visiting {
+		// We have reached a node that is being calculated through a cycle,
+		// therefore we use its value from the previous prediction.
+		return node.state
+	}
+	node.visiting = true
 	val := node.Bias
 	for i := range node.incomming {
 		c := node.incomming[i]
+		if !c.enabled {
+			continue
+		}
 		val += calculate(c.inNode) * c.weight
 	}
+	node.visiting = false
+	val = node.Activation.Apply(val)
 	node.tempVal = val
 	node.tempSet = true
 	return val
 }
 
-// Clone returns a clone of the network.
+// Clone returns a clone of the network. The clone shares the random source and
+// the innovation registry with the original network. The remembered values of
+// a recurrent network are not cloned.
 func (n *NEAT) Clone() *NEAT {
-	node := &NEAT{
-		nodes:   make([]*Node, 0, len(n.nodes)),
-		inputs:  n.inputs,
-		outputs: n.outputs,
+	clone := &NEAT{
+		nodes:        make([]*Node, len(n.nodes)),
+		inputs:       n.inputs,
+		outputs:      n.outputs,
+		mutationRate: n.mutationRate,
+		rand:         n.rand,
+		innovations:  n.innovations,
+		recurrent:    n.recurrent,
 	}
-	// we need to keep a cache of nodes in case there was a multiple connection
-	// to the same node.
+	// We need to create all the nodes first, so the connections can point to
+	// the cloned nodes regardless of their order.
 	nodeMap := make(map[int]*Node, len(n.nodes))
-
-	var cloneNode func(node *Node) *Node
-	cloneNode = func(node *Node) *Node {
-		n := &Node{
-			NodeType:  node.NodeType,
-			Bias:      node.Bias,
-			ID:        node.ID,
-			incomming: make([]*Connection, len(node.incomming)),
+	for i, node := range n.nodes {
+		clone.nodes[i] = &Node{
+			NodeType:   node.NodeType,
+			Bias:       node.Bias,
+			ID:         node.ID,
+			Activation: node.Activation,
 		}
-		for i := range node.incomming {
-			tmp, ok := nodeMap[node.incomming[i].inNode.ID]
-			if !ok {
-				tmp = cloneNode(node.incomming[i].inNode)
-			}
-			n.incomming[i] = &Connection{
-				inNode:     tmp,
-				outNode:    n,
-				weight:     node.incomming[i].weight,
-				enabled:    node.incomming[i].enabled,
-				innovation: node.incomming[i].innovation,
+		nodeMap[node.ID] = clone.nodes[i]
+	}
+	for i, node := range n.nodes {
+		c := clone.nodes[i]
+		c.incomming = make([]*Connection, len(node.incomming))
+		for j, conn := range node.incomming {
+			c.incomming[j] = &Connection{
+				inNode:     nodeMap[conn.inNode.ID],
+				outNode:    c,
+				weight:     conn.weight,
+				enabled:    conn.enabled,
+				innovation: conn.innovation,
 			}
-			nodeMap[node.incomming[i].inNode.ID] = tmp
 		}
-		return n
 	}
+	return clone
+}
 
-	for i := range n.nodes {
-		node.nodes[i] = cloneNode(n.nodes[i])
+// disabledGeneChance is the chance of a gene being disabled in the child if it
+// is disabled in either parent.
+const disabledGeneChance = 0.75
+
+// Crossover breeds the two networks and returns a new child. The fitter
+// network decides the structure of the child: matching genes, that are genes
+// with the same innovation number, are inherited randomly from either parent,
+// and the disjoint and excess genes are only inherited from the fitter parent.
+// If a matching gene is disabled in either parent, there is a 75% chance that
+// it stays disabled in the child. Unless the fitter parent is recurrent, any
+// gene that would create a cycle in the child is dropped. The child shares the
+// random source, the innovation registry and the recurrent mode of the fitter
+// parent.
+func Crossover(fitter, other *NEAT) *NEAT {
+	rand := fitter.rand
+	child := &NEAT{
+		nodes:        make([]*Node, len(fitter.nodes)),
+		inputs:       fitter.inputs,
+		outputs:      fitter.outputs,
+		mutationRate: fitter.mutationRate,
+		rand:         rand,
+		innovations:  fitter.innovations,
+		recurrent:    fitter.recurrent,
+	}
+
+	otherNodes := make(map[int]*Node, len(other.nodes))
+	for _, node := range other.nodes {
+		otherNodes[node.ID] = node
+	}
+	nodeMap := make(map[int]*Node, len(fitter.nodes))
+	for i, node := range fitter.nodes {
+		// The bias and the activation are inherited together.
+		parent := node
+		if match, ok := otherNodes[node.ID]; ok && rand.Intn(2) == 0 {
+			parent = match
+		}
+		child.nodes[i] = &Node{
+			NodeType:   node.NodeType,
+			Bias:       parent.Bias,
+			ID:         node.ID,
+			Activation: parent.Activation,
+		}
+		nodeMap[node.ID] = child.nodes[i]
+	}
+
+	otherGenes := make(map[int32]*Connection, len(other.nodes)*2)
+	for _, gene := range other.connections() {
+		otherGenes[gene.innovation] = gene
 	}
-	return node
+	for i, node := range fitter.nodes {
+		out := child.nodes[i]
+		for _, gene := range node.incomming {
+			weight := gene.weight
+			enabled := gene.enabled
+			if match, ok := otherGenes[gene.innovation]; ok {
+				if rand.Intn(2) == 0 {
+					weight = match.weight
+				}
+				if !gene.enabled || !match.enabled {
+					enabled = rand.Float64() >= disabledGeneChance
+				}
+			}
+			// The structure always comes from the fitter parent, so the nodes
+			// exist in the child.
+			in := nodeMap[gene.inNode.ID]
+			if out.connectionFrom(in.ID) != nil {
+				continue
+			}
+			if !child.recurrent && in.hasConnectionFrom(out) {
+				continue
+			}
+			out.incomming = append(out.incomming, &Connection{
+				inNode:     in,
+				outNode:    out,
+				weight:     weight,
+				enabled:    enabled,
+				innovation: gene.innovation,
+			})
+		}
+	}
+	return child
 }
 
 // Mutate mutates the NEAT. There is a 10% chance of each mutation. It might
-// add a new node, delete a node, split a connection, add a new connection or
-// delete a connection.
+// add a new node, delete a node, split a connection, add a new connection,
+// delete a connection, or change a bias, a weight or an activation.
 func (n *NEAT) Mutate() *NEAT {
 	newNode := n.rand.Intn(100) < n.mutationRate
 	deleteNode := n.rand.Intn(100) < n.mutationRate
@@ -254,6 +497,7 @@ func (n *NEAT) Mutate() *NEAT {
 	toggleConnection := n.rand.Intn(100) < n.mutationRate
 	changeBias := n.rand.Intn(100) < n.mutationRate
 	changeWeight := n.rand.Intn(100) < n.mutationRate
+	changeActivation := n.rand.Intn(100) < n.mutationRate
 	switch {
 	case newNode:
 		n.addRandomNode()
@@ -271,140 +515,171 @@ func (n *NEAT) Mutate() *NEAT {
 		n.changeRandomBias()
 	case changeWeight:
 		n.changeRandomWeight()
+	case changeActivation:
+		n.changeRandomActivation()
 	}
 	return n
 }
 
-// findNonCircularNodes finds two nodes that are not connected to each other.
-func (n *NEAT) findNonCircularNodes() (inNode, outNode *Node) {
-	inputNodes := filter(n.nodes, func(n *Node) bool {
-		return n.NodeType == InputNode || n.NodeType == HiddenNode
+// findNonCircularNodes finds two nodes that connecting them would not create a
+// cycle. If unconnected is true, the nodes should not already be directly
+// connected. It returns nil values if it can't find such nodes after a few
+// attempts. In the recurrent mode any node can be connected to a non-input
+// node, including itself.
+func (n *NEAT) findNonCircularNodes(unconnected bool) (inNode, outNode *Node) {
+	inputNodes := filter(n.nodes, func(node *Node) bool {
+		return n.recurrent || node.NodeType == InputNode || node.NodeType == HiddenNode
 	})
 	outputNodes := filter(n.nodes, func(n *Node) bool {
 		return n.NodeType == OutputNode || n.NodeType == HiddenNode
 	})
-	for {
+	for i := 0; i < findNodesAttempts; i++ {
 		in := inputNodes[n.rand.Intn(len(inputNodes))]
 		out := outputNodes[n.rand.Intn(len(outputNodes))]
-		if !in.hasConnectionFrom(out) {
+		if unconnected && out.connectionFrom(in.ID) != nil {
+			continue
+		}
+		// Connecting in to out creates a cycle if out is already feeding in.
+		if n.recurrent || !in.hasConnectionFrom(out) {
 			return in, out
 		}
 	}
+	return nil, nil
 }
 
-// addRandomNode adds a new random node to the network. It connects the node to
-// random nodes. The incomming connection can be any node besides an output
-// node, and the outgoing connection can be any node besides an input node. It
-// prevents cycles in the network.
+// findNodesAttempts is the number of times we try to find two random nodes
+// that can be connected.
+const findNodesAttempts = 20
+
+// addRandomNode adds a new random node to the network with a random
+// activation. It connects the node to random nodes. The outgoing connection
+// can be any node besides an input node. In the feed-forward mode the
+// incomming connection can be any node besides an output node, and the new
+// node doesn't create a cycle. In the recurrent mode the incomming connection
+// can be any node, therefore the new node might create a cycle.
 func (n *NEAT) addRandomNode() {
+	inNode, outNode := n.findNonCircularNodes(false)
+	if inNode == nil {
+		return
+	}
 	node := &Node{
-		NodeType: HiddenNode,
-		Bias:     n.rand.Float64() - 0.5, // [-0.5, 0.5)
-		ID:       len(n.nodes),
+		NodeType:   HiddenNode,
+		Bias:       n.rand.Float64() - 0.5, // [-0.5, 0.5)
+		ID:         n.nodeID(inNode.ID, outNode.ID),
+		Activation: n.randomActivation(),
 	}
-
-	inNode, outNode := n.findNonCircularNodes()
-	connection := &Connection{
+	node.incomming = append(node.incomming, &Connection{
 		inNode:     inNode,
 		outNode:    node,
 		weight:     n.rand.Float64() - 0.5, // [-0.5, 0.5)
 		enabled:    true,
-		innovation: lastInnovation.Add(1),
-	}
-	inNode.incomming = append(inNode.incomming, connection)
-
-	connection = &Connection{
+		innovation: n.innovations.connection(inNode.ID, node.ID),
+	})
+	outNode.incomming = append(outNode.incomming, &Connection{
 		inNode:     node,
 		outNode:    outNode,
 		weight:     n.rand.Float64() - 0.5, // [-0.5, 0.5)
 		enabled:    true,
-		innovation: lastInnovation.Add(1),
-	}
-	node.incomming = append(node.incomming, connection)
+		innovation: n.innovations.connection(node.ID, outNode.ID),
+	})
 
 	n.nodes = append(n.nodes, node)
 }
 
-// deleteRandomNode randomly deletes a node from the network. It deletes all
-// the connections to this node.
+// deleteRandomNode randomly deletes a hidden node from the network. It deletes
+// all the connections to and from this node.
 func (n *NEAT) deleteRandomNode() {
 	// we can't delete input or output nodes.
-	node := filterPick(n.nodes, func(n *Node) bool {
+	node := n.pick(func(n *Node) bool {
 		return n.NodeType == HiddenNode
 	})
+	if node == nil {
+		return
+	}
 
-	// We need to remove all connections to this node.
+	n.nodes = slices.DeleteFunc(n.nodes, func(other *Node) bool {
+		return other == node
+	})
+	// We need to remove all connections from this node. The connections to
+	// this node are removed with the node itself.
 	for i := range n.nodes {
 		n.nodes[i].incomming = slices.DeleteFunc(n.nodes[i].incomming, func(c *Connection) bool {
-			return c.outNode.ID == node.ID
+			return c.inNode == node
 		})
 	}
-	// Now we should normalise the IDs.
-	for i := range n.nodes {
-		n.nodes[i].ID = i + 1
-	}
 }
 
 // splitRandomConnection splits a random connection in the network by adding a
-// new node in between the connection.
+// new node in between the connection. The old connection is disabled, and the
+// new node receives a weight of 1 from the old input, and passes the old
+// weight to the old output. The new node has the Identity activation,
+// therefore the network behaves the same until the new genes are mutated.
 func (n *NEAT) splitRandomConnection() {
-	// We find any nodes that are not input nodes and operate on its incomming
-	// connections. We don't know if the next random node has a connection, so
-	// we filter for one
-	node := filterPick(n.nodes, func(n *Node) bool {
-		return n.NodeType == HiddenNode && len(n.incomming) > 0
-	})
+	node := n.pick(hasIncomming)
+	if node == nil {
+		return
+	}
 	connection := node.incomming[n.rand.Intn(len(node.incomming))]
+	if !connection.enabled {
+		return
+	}
+	connection.enabled = false
 
 	newNode := &Node{
 		NodeType: HiddenNode,
-		Bias:     n.rand.Float64() - 0.5, // [-0.5, 0.5)
+		ID:       n.nodeID(connection.inNode.ID, node.ID),
 	}
-	newConnection := &Connection{
+	newNode.incomming = append(newNode.incomming, &Connection{
 		inNode:     connection.inNode,
 		outNode:    newNode,
-		weight:     n.rand.Float64() - 0.5, // [-0.5, 0.5)
+		weight:     1,
 		enabled:    true,
-		innovation: lastInnovation.Add(1),
-	}
-	node.incomming = append(node.incomming, newConnection)
-	connection.inNode = newNode
-
-	// Now we should normalise the IDs.
-	for i := range n.nodes {
-		n.nodes[i].ID = i + 1
-	}
+		innovation: n.innovations.connection(connection.inNode.ID, newNode.ID),
+	})
+	node.incomming = append(node.incomming, &Connection{
+		inNode:     newNode,
+		outNode:    node,
+		weight:     connection.weight,
+		enabled:    true,
+		innovation: n.innovations.connection(newNode.ID, node.ID),
+	})
+	n.nodes = append(n.nodes, newNode)
 }
 
 // addRandomConnection adds a random connection between two random nodes in the
 // network.
 func (n *NEAT) addRandomConnection() {
-	node1, node2 := n.findNonCircularNodes()
+	node1, node2 := n.findNonCircularNodes(true)
+	if node1 == nil {
+		return
+	}
 	connection := &Connection{
 		inNode:     node1,
 		outNode:    node2,
 		weight:     n.rand.Float64() - 0.5, // [-0.5, 0.5)
 		enabled:    true,
-		innovation: lastInnovation.Add(1),
+		innovation: n.innovations.connection(node1.ID, node2.ID),
 	}
-	node1.incomming = append(node1.incomming, connection)
+	node2.incomming = append(node2.incomming, connection)
 }
 
 // deleteRandomConnection deletes a random connection from the network.
 func (n *NEAT) deleteRandomConnection() {
-	node := filterPick(n.nodes, func(n *Node) bool {
-		return n.NodeType == HiddenNode && len(n.incomming) > 0
-	})
+	node := n.pick(hasIncomming)
+	if node == nil {
+		return
+	}
 
 	index := n.rand.Intn(len(node.incomming))
-	node.incomming = slices.Delete(node.incomming, index, index)
+	node.incomming = slices.Delete(node.incomming, index, index+1)
 }
 
 // toggleRandomConnection toggles a random connection in the network.
 func (n *NEAT) toggleRandomConnection() {
-	node := filterPick(n.nodes, func(n *Node) bool {
-		return n.NodeType == HiddenNode && len(n.incomming) > 0
-	})
+	node := n.pick(hasIncomming)
+	if node == nil {
+		return
+	}
 
 	index := n.rand.Intn(len(node.incomming))
 	node.incomming[index].enabled = !node.incomming[index].enabled
@@ -412,9 +687,12 @@ func (n *NEAT) toggleRandomConnection() {
 
 // changeRandomBias changes the bias of a random node in the network.
 func (n *NEAT) changeRandomBias() {
-	node := filterPick(n.nodes, func(n *Node) bool {
-		return n.NodeType == HiddenNode
+	node := n.pick(func(n *Node) bool {
+		return n.NodeType != InputNode
 	})
+	if node == nil {
+		return
+	}
 	c := float64(1)
 	if n.rand.Intn(100) > 50 {
 		c = -1
@@ -424,9 +702,10 @@ func (n *NEAT) changeRandomBias() {
 
 // changeRandomWeight changes the weight of a random connection in the network.
 func (n *NEAT) changeRandomWeight() {
-	node := filterPick(n.nodes, func(n *Node) bool {
-		return n.NodeType == HiddenNode && len(n.incomming) > 0
-	})
+	node := n.pick(hasIncomming)
+	if node == nil {
+		return
+	}
 
 	index := n.rand.Intn(len(node.incomming))
 	c := float64(1)
@@ -435,3 +714,31 @@ func (n *NEAT) changeRandomWeight() {
 	}
 	node.incomming[index].weight += c * 0.001
 }
+
+// changeRandomActivation changes the activation of a random node in the
+// network.
+func (n *NEAT) changeRandomActivation() {
+	node := n.pick(func(n *Node) bool {
+		return n.NodeType != InputNode
+	})
+	if node == nil {
+		return
+	}
+	node.Activation = n.randomActivation()
+}
+
+// randomActivation returns a random activation from the registry.
+func (n *NEAT) randomActivation() Activation {
+	return Activation(n.rand.Intn(len(activations)))
+}
+
+// SetOutputActivation sets the activation of all the output nodes. This is
+// useful when the outputs should be bounded, for example by the Tanh
+// activation.
+func (n *NEAT) SetOutputActivation(a Activation) {
+	for _, node := range n.nodes {
+		if node.NodeType == OutputNode {
+			node.Activation = a
+		}
+	}
+}
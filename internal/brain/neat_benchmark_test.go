@@ -0,0 +1,40 @@
+package brain_test
+
+import (
+	stdrand "math/rand"
+	"testing"
+
+	"github.com/arsham/neuragene/internal/brain"
+)
+
+func BenchmarkNEAT(b *testing.B) {
+	r := stdrand.New(stdrand.NewSource(1))
+	neat := brain.NewNEAT(8, 4, 40, r)
+	for i := 0; i < 500; i++ {
+		neat.Mutate()
+	}
+	input := []float64{0.1, 0.2, 0.3, -0.1, 0.15, 1, 0, 0.3}
+
+	b.Run("Predict", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err := neat.Predict(input)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Compiled", func(b *testing.B) {
+		compiled := neat.Compile()
+		dst := make([]float64, 4)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			err := compiled.Predict(dst, input)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,379 @@
+package brain
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	stdrand "math/rand"
+	"time"
+)
+
+// neatFormatVersion is the version of the encoded NEAT networks. It should be
+// increased when the format changes in a way that the older versions can't be
+// decoded.
+const neatFormatVersion = 1
+
+// neatMagic is the prefix of the binary encoded NEAT networks.
+var neatMagic = [4]byte{'N', 'E', 'A', 'T'}
+
+// ErrInvalidFormat is returned when the encoded data can't be decoded.
+var ErrInvalidFormat = errors.New("invalid format")
+
+// neatRecord is the JSON form of a NEAT network. The connections are stored in
+// the order of their nodes, so the decoded network sums its inputs in the same
+// order and produces the same results.
+type neatRecord struct {
+	Nodes        []nodeRecord       `json:"nodes"`
+	Connections  []connectionRecord `json:"connections"`
+	Version      int                `json:"version"`
+	Inputs       int                `json:"inputs"`
+	Outputs      int                `json:"outputs"`
+	MutationRate int                `json:"mutation_rate"`
+	Recurrent    bool               `json:"recurrent"`
+}
+
+type nodeRecord struct {
+	Type       string  `json:"type"`
+	Activation string  `json:"activation"`
+	ID         int     `json:"id"`
+	Bias       float64 `json:"bias"`
+}
+
+type connectionRecord struct {
+	In         int     `json:"in"`
+	Out        int     `json:"out"`
+	Weight     float64 `json:"weight"`
+	Innovation int32   `json:"innovation"`
+	Enabled    bool    `json:"enabled"`
+}
+
+// binaryHeader, binaryNode and binaryConnection are the fixed size blocks of
+// the binary form. The header is followed by the nodes and then the
+// connections.
+type binaryHeader struct {
+	Magic        [4]byte
+	Version      uint16
+	Recurrent    uint8
+	_            uint8
+	Inputs       uint32
+	Outputs      uint32
+	MutationRate uint32
+	Nodes        uint32
+	Connections  uint32
+}
+
+type binaryNode struct {
+	Bias       float64
+	ID         int32
+	Type       uint8
+	Activation uint8
+}
+
+type binaryConnection struct {
+	Weight     float64
+	In         int32
+	Out        int32
+	Innovation int32
+	Enabled    uint8
+}
+
+func parseNodeType(s string) (NodeType, error) {
+	for _, t := range []NodeType{InputNode, HiddenNode, OutputNode} {
+		if t.String() == s {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unknown node type %q", ErrInvalidFormat, s)
+}
+
+// MarshalJSON returns the JSON encoding of the network. The random source and
+// the innovation registry are not encoded.
+func (n *NEAT) MarshalJSON() ([]byte, error) {
+	r := neatRecord{
+		Version:      neatFormatVersion,
+		Inputs:       n.inputs,
+		Outputs:      n.outputs,
+		MutationRate: n.mutationRate,
+		Recurrent:    n.recurrent,
+		Nodes:        make([]nodeRecord, len(n.nodes)),
+	}
+	for i, node := range n.nodes {
+		r.Nodes[i] = nodeRecord{
+			ID:         node.ID,
+			Type:       node.NodeType.String(),
+			Bias:       node.Bias,
+			Activation: node.Activation.String(),
+		}
+		for _, c := range node.incomming {
+			r.Connections = append(r.Connections, connectionRecord{
+				In:         c.inNode.ID,
+				Out:        node.ID,
+				Weight:     c.weight,
+				Innovation: c.innovation,
+				Enabled:    c.enabled,
+			})
+		}
+	}
+	return json.Marshal(r)
+}
+
+// UnmarshalJSON decodes the network from the data produced by the MarshalJSON
+// method. See the SetRand and SetInnovations methods for the dependencies
+// that are not encoded.
+func (n *NEAT) UnmarshalJSON(data []byte) error {
+	var r neatRecord
+	if err := json.Unmarshal(data, &r); err != nil {
+		return fmt.Errorf("decoding network: %w", err)
+	}
+	if r.Version != neatFormatVersion {
+		return fmt.Errorf("%w: version %d, want %d", ErrInvalidFormat, r.Version, neatFormatVersion)
+	}
+	nodes := make([]*Node, len(r.Nodes))
+	for i, nr := range r.Nodes {
+		nodeType, err := parseNodeType(nr.Type)
+		if err != nil {
+			return err
+		}
+		activation, err := ParseActivation(nr.Activation)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+		}
+		nodes[i] = &Node{
+			NodeType:   nodeType,
+			Bias:       nr.Bias,
+			ID:         nr.ID,
+			Activation: activation,
+		}
+	}
+	connections := make([]binaryConnection, len(r.Connections))
+	for i, c := range r.Connections {
+		connections[i] = binaryConnection{
+			Weight:     c.Weight,
+			In:         int32(c.In),
+			Out:        int32(c.Out),
+			Innovation: c.Innovation,
+		}
+		if c.Enabled {
+			connections[i].Enabled = 1
+		}
+	}
+	return n.restore(r.Inputs, r.Outputs, r.MutationRate, r.Recurrent, nodes, connections)
+}
+
+// MarshalBinary returns the compact binary encoding of the network. The
+// random source and the innovation registry are not encoded.
+func (n *NEAT) MarshalBinary() ([]byte, error) {
+	conns := 0
+	for _, node := range n.nodes {
+		conns += len(node.incomming)
+	}
+	h := binaryHeader{
+		Magic:        neatMagic,
+		Version:      neatFormatVersion,
+		Inputs:       uint32(n.inputs),
+		Outputs:      uint32(n.outputs),
+		MutationRate: uint32(n.mutationRate),
+		Nodes:        uint32(len(n.nodes)),
+		Connections:  uint32(conns),
+	}
+	if n.recurrent {
+		h.Recurrent = 1
+	}
+	nodes := make([]binaryNode, len(n.nodes))
+	connections := make([]binaryConnection, 0, conns)
+	for i, node := range n.nodes {
+		nodes[i] = binaryNode{
+			Bias:       node.Bias,
+			ID:         int32(node.ID),
+			Type:       uint8(node.NodeType),
+			Activation: uint8(node.Activation),
+		}
+		for _, c := range node.incomming {
+			bc := binaryConnection{
+				Weight:     c.weight,
+				In:         int32(c.inNode.ID),
+				Out:        int32(node.ID),
+				Innovation: c.innovation,
+			}
+			if c.enabled {
+				bc.Enabled = 1
+			}
+			connections = append(connections, bc)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	for _, v := range []any{h, nodes, connections} {
+		if err := binary.Write(buf, binary.LittleEndian, v); err != nil {
+			return nil, fmt.Errorf("encoding network: %w", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the network from the data produced by the
+// MarshalBinary method. See the SetRand and SetInnovations methods for the
+// dependencies that are not encoded.
+func (n *NEAT) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	var h binaryHeader
+	if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+		return fmt.Errorf("%w: reading header: %w", ErrInvalidFormat, err)
+	}
+	if h.Magic != neatMagic {
+		return fmt.Errorf("%w: not a NEAT network", ErrInvalidFormat)
+	}
+	if h.Version != neatFormatVersion {
+		return fmt.Errorf("%w: version %d, want %d", ErrInvalidFormat, h.Version, neatFormatVersion)
+	}
+	// Each node takes at least one byte, so we can reject corrupted headers
+	// before allocating memory.
+	if int(h.Nodes)+int(h.Connections) > len(data) {
+		return fmt.Errorf("%w: %d nodes and %d connections in %d bytes", ErrInvalidFormat, h.Nodes, h.Connections, len(data))
+	}
+	bNodes := make([]binaryNode, h.Nodes)
+	if err := binary.Read(r, binary.LittleEndian, bNodes); err != nil {
+		return fmt.Errorf("%w: reading nodes: %w", ErrInvalidFormat, err)
+	}
+	connections := make([]binaryConnection, h.Connections)
+	if err := binary.Read(r, binary.LittleEndian, connections); err != nil {
+		return fmt.Errorf("%w: reading connections: %w", ErrInvalidFormat, err)
+	}
+	if r.Len() != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrInvalidFormat, r.Len())
+	}
+
+	nodes := make([]*Node, len(bNodes))
+	for i, bn := range bNodes {
+		nodeType := NodeType(bn.Type)
+		if nodeType != InputNode && nodeType != HiddenNode && nodeType != OutputNode {
+			return fmt.Errorf("%w: unknown node type %d", ErrInvalidFormat, bn.Type)
+		}
+		nodes[i] = &Node{
+			NodeType:   nodeType,
+			Bias:       bn.Bias,
+			ID:         int(bn.ID),
+			Activation: Activation(bn.Activation),
+		}
+	}
+	return n.restore(int(h.Inputs), int(h.Outputs), int(h.MutationRate), h.Recurrent == 1, nodes, connections)
+}
+
+// restore validates the decoded values and replaces the network with them.
+func (n *NEAT) restore(inputs, outputs, mutationRate int, recurrent bool, nodes []*Node, connections []binaryConnection) error {
+	nodeMap := make(map[int]*Node, len(nodes))
+	counts := make(map[NodeType]int, 3)
+	maxID := 0
+	for i, node := range nodes {
+		if _, ok := nodeMap[node.ID]; ok {
+			return fmt.Errorf("%w: duplicate node %d", ErrInvalidFormat, node.ID)
+		}
+		if !node.Activation.Valid() {
+			return fmt.Errorf("%w: node %d: %s", ErrInvalidFormat, node.ID, node.Activation)
+		}
+		// The input nodes should always come first.
+		if (i < inputs) != (node.NodeType == InputNode) {
+			return fmt.Errorf("%w: unexpected %s at %d", ErrInvalidFormat, node.NodeType, i)
+		}
+		nodeMap[node.ID] = node
+		counts[node.NodeType]++
+		maxID = max(maxID, node.ID)
+	}
+	if counts[InputNode] != inputs || counts[OutputNode] != outputs {
+		return fmt.Errorf("%w: %d inputs and %d outputs, want %d and %d",
+			ErrInvalidFormat, counts[InputNode], counts[OutputNode], inputs, outputs)
+	}
+
+	var maxInnovation int32
+	for _, c := range connections {
+		in, ok := nodeMap[int(c.In)]
+		if !ok {
+			return fmt.Errorf("%w: unknown node %d", ErrInvalidFormat, c.In)
+		}
+		out, ok := nodeMap[int(c.Out)]
+		if !ok || out.NodeType == InputNode {
+			return fmt.Errorf("%w: invalid output node %d", ErrInvalidFormat, c.Out)
+		}
+		if out.connectionFrom(in.ID) != nil {
+			return fmt.Errorf("%w: duplicate connection %d->%d", ErrInvalidFormat, c.In, c.Out)
+		}
+		// The mutations of a feed-forward network expect it to be acyclic.
+		if !recurrent && in.hasConnectionFrom(out) {
+			return fmt.Errorf("%w: connection %d->%d creates a cycle in a feed-forward network",
+				ErrInvalidFormat, c.In, c.Out)
+		}
+		out.incomming = append(out.incomming, &Connection{
+			inNode:     in,
+			outNode:    out,
+			weight:     c.Weight,
+			enabled:    c.Enabled == 1,
+			innovation: c.Innovation,
+		})
+		maxInnovation = max(maxInnovation, c.Innovation)
+	}
+
+	n.nodes = nodes
+	n.inputs = inputs
+	n.outputs = outputs
+	n.mutationRate = mutationRate
+	n.recurrent = recurrent
+	if n.rand == nil {
+		n.rand = stdrand.New(stdrand.NewSource(time.Now().UnixNano()))
+	}
+	if n.innovations == nil {
+		n.innovations = NewInnovations()
+	}
+	n.innovations.reserve(maxID, maxInnovation)
+	return nil
+}
+
+// SetRand sets the random source of the network. It is useful after decoding
+// a network, which otherwise receives a time seeded random source.
+func (n *NEAT) SetRand(rand *stdrand.Rand) {
+	n.rand = rand
+}
+
+// SetInnovations sets the innovation registry of the network, and makes sure
+// the registry won't give out the numbers that the network already uses. It
+// is useful for adding a decoded network to a population, otherwise the
+// network receives its own registry.
+func (n *NEAT) SetInnovations(innovations *Innovations) {
+	maxID := 0
+	var maxInnovation int32
+	for _, node := range n.nodes {
+		maxID = max(maxID, node.ID)
+		for _, c := range node.incomming {
+			maxInnovation = max(maxInnovation, c.innovation)
+		}
+	}
+	innovations.reserve(maxID, maxInnovation)
+	n.innovations = innovations
+}
+
+// ReadNEAT decodes a network from r. It detects whether the data is in the
+// binary or the JSON form.
+func ReadNEAT(r io.Reader) (*NEAT, error) {
+	br := bufio.NewReader(r)
+	prefix, err := br.Peek(len(neatMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("reading network: %w", err)
+	}
+	data, err := io.ReadAll(br)
+	if err != nil {
+		return nil, fmt.Errorf("reading network: %w", err)
+	}
+	n := &NEAT{}
+	if bytes.Equal(prefix, neatMagic[:]) {
+		err = n.UnmarshalBinary(data)
+	} else {
+		err = n.UnmarshalJSON(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
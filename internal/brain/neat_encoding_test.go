@@ -0,0 +1,194 @@
+package brain
+
+import (
+	"bytes"
+	"encoding/json"
+	stdrand "math/rand"
+	"slices"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestNEATEncoding(t *testing.T) {
+	t.Parallel()
+	t.Run("JSON", testNEATEncodingJSON)
+	t.Run("Binary", testNEATEncodingBinary)
+	t.Run("ReadNEAT", testNEATEncodingReadNEAT)
+	t.Run("Invalid", testNEATEncodingInvalid)
+	t.Run("SetInnovations", testNEATEncodingSetInnovations)
+}
+
+// evolvedNetworks returns a feed-forward and a recurrent network with a few
+// hidden nodes, disabled connections and various activations.
+func evolvedNetworks(r *stdrand.Rand) []*NEAT {
+	ff := NewNEAT(5, 3, 40, r)
+	rec := NewNEAT(5, 3, 40, r)
+	rec.SetRecurrent(true)
+	for i := 0; i < 200; i++ {
+		ff.Mutate()
+		rec.Mutate()
+	}
+	return []*NEAT{ff, rec}
+}
+
+// assertSameNetwork checks that the two networks have the same genes and
+// produce the same outputs.
+func assertSameNetwork(t *testing.T, want, got *NEAT) {
+	t.Helper()
+	assert.Equal(t, want.inputs, got.inputs)
+	assert.Equal(t, want.outputs, got.outputs)
+	assert.Equal(t, want.mutationRate, got.mutationRate)
+	assert.Equal(t, want.recurrent, got.recurrent)
+	wantData, err := want.MarshalJSON()
+	assert.NoError(t, err)
+	gotData, err := got.MarshalJSON()
+	assert.NoError(t, err)
+	assert.Equal(t, string(wantData), string(gotData))
+	assert.Equal(t, innovations(want), innovations(got))
+	for i := range want.nodes {
+		assert.Equal(t, want.nodes[i].Activation, got.nodes[i].Activation)
+	}
+
+	want.Reset()
+	input := []float64{0.1, -0.2, 0.3, 0.4, -0.5}
+	for i := 0; i < 3; i++ {
+		a, err := want.Predict(input)
+		assert.NoError(t, err)
+		b, err := got.Predict(input)
+		assert.NoError(t, err)
+		assert.Equal(t, a, b)
+	}
+}
+
+func testNEATEncodingJSON(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	for _, n := range evolvedNetworks(r) {
+		data, err := json.Marshal(n)
+		assert.NoError(t, err)
+		again, err := json.Marshal(n)
+		assert.NoError(t, err)
+		assert.Equal(t, string(data), string(again))
+
+		got := &NEAT{}
+		err = json.Unmarshal(data, got)
+		assert.NoError(t, err)
+		assertSameNetwork(t, n, got)
+		got.Mutate()
+	}
+}
+
+func testNEATEncodingBinary(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(2))
+	for _, n := range evolvedNetworks(r) {
+		data, err := n.MarshalBinary()
+		assert.NoError(t, err)
+		jsonData, err := n.MarshalJSON()
+		assert.NoError(t, err)
+		assert.True(t, len(data) < len(jsonData), "binary %d >= json %d bytes", len(data), len(jsonData))
+
+		got := &NEAT{}
+		err = got.UnmarshalBinary(data)
+		assert.NoError(t, err)
+		assertSameNetwork(t, n, got)
+		got.Mutate()
+	}
+}
+
+func testNEATEncodingReadNEAT(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(3))
+	n := evolvedNetworks(r)[0]
+	binaryData, err := n.MarshalBinary()
+	assert.NoError(t, err)
+	jsonData, err := n.MarshalJSON()
+	assert.NoError(t, err)
+
+	for _, data := range [][]byte{binaryData, jsonData} {
+		got, err := ReadNEAT(bytes.NewReader(data))
+		assert.NoError(t, err)
+		assertSameNetwork(t, n, got)
+	}
+	_, err = ReadNEAT(bytes.NewReader(nil))
+	assert.Error(t, err)
+}
+
+func testNEATEncodingInvalid(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(4))
+	n := NewNEAT(2, 1, 0, r)
+	data, err := n.MarshalBinary()
+	assert.NoError(t, err)
+
+	tcs := map[string]func([]byte) []byte{
+		"magic":     func(b []byte) []byte { b[0] = 'X'; return b },
+		"version":   func(b []byte) []byte { b[4] = 99; return b },
+		"truncated": func(b []byte) []byte { return b[:len(b)-1] },
+		"trailing":  func(b []byte) []byte { return append(b, 0) },
+		"inputs":    func(b []byte) []byte { b[8] = 3; return b },
+	}
+	for name, fn := range tcs {
+		fn := fn
+		t.Run("binary "+name, func(t *testing.T) {
+			t.Parallel()
+			err := (&NEAT{}).UnmarshalBinary(fn(bytes.Clone(data)))
+			assert.IsError(t, err, ErrInvalidFormat)
+		})
+	}
+
+	jsonTcs := map[string]string{
+		"version":    `{"version":2,"inputs":1,"outputs":1}`,
+		"node type":  `{"version":1,"inputs":1,"outputs":0,"nodes":[{"id":1,"type":"Foo","activation":"identity"}]}`,
+		"activation": `{"version":1,"inputs":1,"outputs":0,"nodes":[{"id":1,"type":"InputNode","activation":"foo"}]}`,
+		"duplicate": `{"version":1,"inputs":1,"outputs":1,"nodes":[` +
+			`{"id":1,"type":"InputNode","activation":"identity"},{"id":1,"type":"OutputNode","activation":"identity"}]}`,
+		"connection": `{"version":1,"inputs":1,"outputs":1,"nodes":[` +
+			`{"id":1,"type":"InputNode","activation":"identity"},{"id":2,"type":"OutputNode","activation":"identity"}],` +
+			`"connections":[{"in":3,"out":2}]}`,
+		"order": `{"version":1,"inputs":1,"outputs":1,"nodes":[` +
+			`{"id":2,"type":"OutputNode","activation":"identity"},{"id":1,"type":"InputNode","activation":"identity"}]}`,
+		"duplicate connection": `{"version":1,"inputs":1,"outputs":1,"nodes":[` +
+			`{"id":1,"type":"InputNode","activation":"identity"},{"id":2,"type":"OutputNode","activation":"identity"}],` +
+			`"connections":[{"in":1,"out":2,"innovation":1},{"in":1,"out":2,"innovation":2}]}`,
+		"self-loop": `{"version":1,"inputs":1,"outputs":1,"nodes":[` +
+			`{"id":1,"type":"InputNode","activation":"identity"},{"id":2,"type":"OutputNode","activation":"identity"}],` +
+			`"connections":[{"in":2,"out":2}]}`,
+		"cycle": `{"version":1,"inputs":1,"outputs":1,"nodes":[` +
+			`{"id":1,"type":"InputNode","activation":"identity"},{"id":2,"type":"OutputNode","activation":"identity"},` +
+			`{"id":3,"type":"HiddenNode","activation":"identity"}],` +
+			`"connections":[{"in":1,"out":3},{"in":3,"out":2},{"in":2,"out":3}]}`,
+	}
+	for name, data := range jsonTcs {
+		data := data
+		t.Run("json "+name, func(t *testing.T) {
+			t.Parallel()
+			err := (&NEAT{}).UnmarshalJSON([]byte(data))
+			assert.IsError(t, err, ErrInvalidFormat)
+		})
+	}
+
+	// The cycles are only allowed in the recurrent networks.
+	cycle := `{"version":1,"inputs":1,"outputs":1,"recurrent":true,"nodes":[` +
+		`{"id":1,"type":"InputNode","activation":"identity"},{"id":2,"type":"OutputNode","activation":"identity"}],` +
+		`"connections":[{"in":1,"out":2},{"in":2,"out":2}]}`
+	assert.NoError(t, (&NEAT{}).UnmarshalJSON([]byte(cycle)))
+}
+
+func testNEATEncodingSetInnovations(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(5))
+	n := evolvedNetworks(r)[0]
+	data, err := n.MarshalBinary()
+	assert.NoError(t, err)
+
+	got := &NEAT{}
+	err = got.UnmarshalBinary(data)
+	assert.NoError(t, err)
+	registry := NewInnovations()
+	got.SetInnovations(registry)
+	got.SetRand(r)
+	last := slices.Max(innovations(n))
+	assert.True(t, registry.connection(-1, -2) > last)
+}
@@ -1,7 +1,10 @@
 package brain
 
 import (
+	"encoding/json"
+	"math"
 	stdrand "math/rand"
+	"slices"
 	"testing"
 
 	"github.com/alecthomas/assert/v2"
@@ -25,10 +28,10 @@ func testNodeHasConnectionFrom(t *testing.T) {
 	d := &Node{ID: 4}
 	e := &Node{ID: 5}
 	f := &Node{ID: 6}
-	e.incomming = []*Connection{{inNode: e, outNode: d}}
-	d.incomming = []*Connection{{inNode: d, outNode: a}, {inNode: d, outNode: f}}
-	c.incomming = []*Connection{{inNode: c, outNode: b}}
-	b.incomming = []*Connection{{inNode: b, outNode: a}}
+	e.incomming = []*Connection{{inNode: d, outNode: e}}
+	d.incomming = []*Connection{{inNode: a, outNode: d}, {inNode: f, outNode: d}}
+	c.incomming = []*Connection{{inNode: b, outNode: c}}
+	b.incomming = []*Connection{{inNode: a, outNode: b}}
 
 	testCases := map[string]struct {
 		from *Node
@@ -60,6 +63,9 @@ func TestNEAT(t *testing.T) {
 	t.Parallel()
 	t.Run("NewNEAT", testNEATNewNEAT)
 	t.Run("Predict", testNEATPredict)
+	t.Run("Clone", testNEATClone)
+	t.Run("Mutate", testNEATMutate)
+	t.Run("Crossover", testNEATCrossover)
 }
 
 func testNEATNewNEAT(t *testing.T) {
@@ -89,4 +95,180 @@ func testNEATPredict(t *testing.T) {
 	if !almostEqual(v, want, 0.0001) {
 		t.Errorf("Predicted output %v does not match expected output %v", v, want)
 	}
+
+	// The inputs are not written into the biases of the input nodes,
+	// therefore predicting doesn't change the genome.
+	biases := make([]float64, len(neat.nodes))
+	for i, node := range neat.nodes {
+		biases[i] = node.Bias
+	}
+	before, err := json.Marshal(neat)
+	assert.NoError(t, err)
+	_, err = neat.Predict([]float64{5, 6, 7, 8, 9, 10, 11, 12})
+	assert.NoError(t, err)
+	for i, node := range neat.nodes {
+		assert.Equal(t, biases[i], node.Bias, "node %d", node.ID)
+	}
+	after, err := json.Marshal(neat)
+	assert.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+}
+
+// isAcyclic returns true if none of the connections of the network create a
+// cycle. It is safe to use on recurrent networks.
+func isAcyclic(n *NEAT) bool {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[*Node]int, len(n.nodes))
+	var visit func(node *Node) bool
+	visit = func(node *Node) bool {
+		switch state[node] {
+		case visiting:
+			return false
+		case done:
+			return true
+		}
+		state[node] = visiting
+		for _, c := range node.incomming {
+			if !visit(c.inNode) {
+				return false
+			}
+		}
+		state[node] = done
+		return true
+	}
+	for _, node := range n.nodes {
+		if !visit(node) {
+			return false
+		}
+	}
+	return true
+}
+
+// innovations returns the innovation numbers of all connections of the
+// network in order.
+func innovations(n *NEAT) []int32 {
+	conns := n.connections()
+	ret := make([]int32, len(conns))
+	for i := range conns {
+		ret[i] = conns[i].innovation
+	}
+	return ret
+}
+
+// evolved returns a network that has been mutated the given amount of times.
+func evolved(parent *NEAT, generations int) *NEAT {
+	n := parent.Clone()
+	for i := 0; i < generations; i++ {
+		n.Mutate()
+	}
+	return n
+}
+
+func testNEATClone(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(2))
+	neat := evolved(NewNEAT(4, 2, 50, r), 50)
+	clone := neat.Clone()
+	assert.Equal(t, innovations(neat), innovations(clone))
+	assert.Equal(t, len(neat.nodes), len(clone.nodes))
+
+	input := []float64{0.1, 0.2, 0.3, 0.4}
+	want, err := neat.Predict(input)
+	assert.NoError(t, err)
+	got, err := clone.Predict(input)
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	clone.nodes[0].incomming = nil
+	for _, node := range clone.nodes {
+		for _, c := range node.incomming {
+			assert.True(t, c.outNode == node, "connection %s is not pointing to its node", c)
+			assert.True(t, slices.Contains(clone.nodes, c.inNode), "connection %s is pointing to the original network", c)
+		}
+	}
+}
+
+func testNEATMutate(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(3))
+	neat := NewNEAT(5, 3, 30, r)
+	for i := 0; i < 1000; i++ {
+		neat.Mutate()
+		assert.True(t, isAcyclic(neat), "mutation %d created a cycle", i)
+		_, err := neat.Predict([]float64{1, 2, 3, 4, 5})
+		assert.NoError(t, err)
+	}
+}
+
+func testNEATCrossover(t *testing.T) {
+	t.Parallel()
+	t.Run("Structure", testNEATCrossoverStructure)
+	t.Run("MatchingGenes", testNEATCrossoverMatchingGenes)
+	t.Run("DisabledGenes", testNEATCrossoverDisabledGenes)
+}
+
+func testNEATCrossoverStructure(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(4))
+	parent := NewNEAT(4, 2, 40, r)
+	for i := 0; i < 20; i++ {
+		fitter := evolved(parent, 30)
+		other := evolved(parent, 30)
+		child := Crossover(fitter, other)
+
+		assert.True(t, isAcyclic(child), "child has a cycle")
+		assert.Equal(t, innovations(fitter), innovations(child))
+		assert.Equal(t, len(fitter.nodes), len(child.nodes))
+		for j := range fitter.nodes {
+			assert.Equal(t, fitter.nodes[j].ID, child.nodes[j].ID)
+		}
+		_, err := child.Predict([]float64{1, 2, 3, 4})
+		assert.NoError(t, err)
+	}
+}
+
+func testNEATCrossoverMatchingGenes(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(5))
+	fitter := NewNEAT(8, 4, 0, r)
+	other := fitter.Clone()
+	for _, c := range other.connections() {
+		c.weight += 10
+	}
+
+	fromOther := 0
+	child := Crossover(fitter, other)
+	conns := child.connections()
+	for i, c := range fitter.connections() {
+		got := conns[i].weight
+		if got != c.weight {
+			assert.Equal(t, c.weight+10, got)
+			fromOther++
+		}
+	}
+	assert.True(t, fromOther > 0, "no genes were inherited from the other parent")
+	assert.True(t, fromOther < len(conns), "all genes were inherited from the other parent")
+}
+
+func testNEATCrossoverDisabledGenes(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(6))
+	fitter := NewNEAT(1, 1, 0, r)
+	other := fitter.Clone()
+	other.connections()[0].enabled = false
+
+	const total = 10000
+	disabled := 0
+	for i := 0; i < total; i++ {
+		child := Crossover(fitter, other)
+		if !child.connections()[0].enabled {
+			disabled++
+		}
+	}
+	ratio := float64(disabled) / total
+	assert.True(t, math.Abs(ratio-disabledGeneChance) < 0.02, "got %f disabled ratio", ratio)
 }
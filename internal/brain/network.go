@@ -1,224 +1,235 @@
 package brain
 
 import (
-	"archive/tar"
 	"errors"
 	"fmt"
-	"io"
-	"math"
-	"os"
 
 	"gonum.org/v1/gonum/mat"
-
-	"github.com/arsham/neuragene/internal/config"
 )
 
-// Layer is a layer of the neural network.
+// Layer is a layer of the neural network. The number of neurons of the layer
+// is the length of its biases. The weights are stored row by row, where each
+// row holds the weights from one neuron of the previous layer to all neurons
+// of this layer.
 type Layer struct {
 	Weights []float64
 	Biases  []float64
+	// Activation is applied to the output of the neurons. The zero value is
+	// the Identity activation.
+	Activation Activation
 }
 
 // Config is the configuration for the neural network. On production you should
 // not set the TestCheck to true, otherwise it will check the input values and
 // it will slow down the process.
 type Config struct {
-	HiddenLayer   Layer
-	OutputLayer   Layer
-	InputNeurons  int
+	// Layers are the hidden layers followed by the output layer. If it is
+	// empty, the HiddenLayer and the OutputLayer are used instead.
+	Layers []Layer
+	// HiddenLayer is the only hidden layer of the network. Its activation is
+	// always Sigmoid.
+	//
+	// Deprecated: use Layers.
+	HiddenLayer Layer
+	// OutputLayer is the output layer of the network. Its activation is
+	// always Sigmoid.
+	//
+	// Deprecated: use Layers.
+	OutputLayer  Layer
+	InputNeurons int
+	// OutputNeurons is required when the Layers are not set. Otherwise it is
+	// optional, and when it is set it should match the size of the last
+	// layer.
 	OutputNeurons int
 	TestCheck     bool // for testing
 }
 
+// layers returns the layers of the network, converting the deprecated fields
+// if the Layers are not set.
+func (c *Config) layers() []Layer {
+	if len(c.Layers) > 0 {
+		return c.Layers
+	}
+	hidden := c.HiddenLayer
+	hidden.Activation = Sigmoid
+	output := c.OutputLayer
+	output.Activation = Sigmoid
+	return []Layer{hidden, output}
+}
+
+// validate returns an error if the shape of the layers don't match each
+// other.
+func (c *Config) validate() error {
+	if c.InputNeurons < 1 {
+		return errors.New("zero input neurons")
+	}
+	if len(c.Layers) == 0 && c.OutputNeurons < 1 {
+		return errors.New("zero output neurons")
+	}
+	layers := c.layers()
+	prev := c.InputNeurons
+	for i, l := range layers {
+		if len(l.Biases) == 0 {
+			return fmt.Errorf("layer %d has no neurons", i)
+		}
+		if len(l.Weights) != prev*len(l.Biases) {
+			return fmt.Errorf("layer %d weights size %d does not match %d", i, len(l.Weights), prev*len(l.Biases))
+		}
+		if !l.Activation.Valid() {
+			return fmt.Errorf("layer %d: %w: %s", i, ErrUnknownActivation, l.Activation)
+		}
+		prev = len(l.Biases)
+	}
+	if c.OutputNeurons > 0 && c.OutputNeurons != prev {
+		return fmt.Errorf("output layer size %d does not match %d output neurons", prev, c.OutputNeurons)
+	}
+	return nil
+}
+
 type layer struct {
-	weights *mat.Dense
-	biases  *mat.Dense
+	weights    *mat.Dense
+	biases     *mat.Dense
+	activation Activation
 }
 
-// Network is a fully connected multi-layer network with one hidden layer.
+// activate applies the activation of the layer to every element of m.
+func (l *layer) activate(m *mat.Dense) {
+	m.Apply(func(_, _ int, v float64) float64 {
+		return l.activation.Apply(v)
+	}, m)
+}
+
+// Network is a fully connected multi-layer network.
 type Network struct {
-	hidden       layer
-	output       layer
+	layers       []layer
 	inputNeurons int
 }
 
-// New creates a neural network with the input weights, hidden weights and with
-// the amount of given output nodes.
+// New creates a neural network with the given input neurons and layers.
 func New(c *Config) (*Network, error) {
 	if c.TestCheck {
 		// Only applied in testing to reduce the amount of checks in
 		// production.
-		if c.InputNeurons < 1 {
-			return nil, errors.New("zero input neurons")
-		}
-		if c.OutputNeurons < 1 {
-			return nil, errors.New("zero output neurons")
-		}
-		if len(c.HiddenLayer.Weights) != c.InputNeurons*len(c.HiddenLayer.Biases) {
-			return nil, fmt.Errorf("hidden layer weights size %d does not match %d", len(c.HiddenLayer.Weights), c.InputNeurons*len(c.HiddenLayer.Biases))
-		}
-		if len(c.OutputLayer.Weights) != len(c.HiddenLayer.Biases)*c.OutputNeurons {
-			return nil, fmt.Errorf("output layer weights size %d does not match %d", len(c.OutputLayer.Weights), len(c.HiddenLayer.Biases)*c.OutputNeurons)
+		if err := c.validate(); err != nil {
+			return nil, err
 		}
 	}
 
-	hiddenNeurons := len(c.HiddenLayer.Weights) / c.InputNeurons
+	layers := c.layers()
 	n := &Network{
 		inputNeurons: c.InputNeurons,
-		hidden: layer{
-			weights: mat.NewDense(c.InputNeurons, hiddenNeurons, c.HiddenLayer.Weights),
-			biases:  mat.NewDense(1, hiddenNeurons, c.HiddenLayer.Biases),
-		},
-		output: layer{
-			weights: mat.NewDense(hiddenNeurons, c.OutputNeurons, c.OutputLayer.Weights),
-			biases:  mat.NewDense(1, c.OutputNeurons, c.OutputLayer.Biases),
-		},
+		layers:       make([]layer, len(layers)),
+	}
+	prev := c.InputNeurons
+	for i, l := range layers {
+		neurons := len(l.Weights) / prev
+		n.layers[i] = layer{
+			weights:    mat.NewDense(prev, neurons, l.Weights),
+			biases:     mat.NewDense(1, neurons, l.Biases),
+			activation: l.Activation,
+		}
+		prev = neurons
 	}
 	return n, nil
 }
 
-func sigmoid(_, _ int, z float64) float64 {
-	return 1.0 / (1 + math.Exp(-z))
-}
-
 // Predict makes a prediction based on a trained neural network.
 func (n *Network) Predict(input []float64) ([]float64, error) {
 	if len(input) != n.inputNeurons {
 		return nil, fmt.Errorf("wrong input size: %d, want %d", len(input), n.inputNeurons)
 	}
-	hiddenIn := &mat.Dense{}
-	inputMat := mat.NewDense(1, len(input), input)
-	hiddenIn.Mul(inputMat, n.hidden.weights)
-	hiddenIn.Apply(func(_, j int, v float64) float64 {
-		return v + n.hidden.biases.At(0, j)
-	}, hiddenIn)
-
-	hiddenActivation := &mat.Dense{}
-	hiddenActivation.Apply(sigmoid, hiddenIn)
-
-	outputIn := &mat.Dense{}
-	outputIn.Mul(hiddenActivation, n.output.weights)
-	outputIn.Apply(func(_, col int, v float64) float64 {
-		return v + n.output.biases.At(0, col)
-	}, outputIn)
-
-	output := &mat.Dense{}
-	output.Apply(sigmoid, outputIn)
-	return output.RawMatrix().Data, nil
+	m := mat.NewDense(1, len(input), input)
+	for i := range n.layers {
+		l := &n.layers[i]
+		out := &mat.Dense{}
+		out.Mul(m, l.weights)
+		out.Add(out, l.biases)
+		l.activate(out)
+		m = out
+	}
+	return m.RawMatrix().Data, nil
 }
 
-const (
-	hiddenWeightsBlob = "hidden_weights.blob"
-	hiddenBiasesBlob  = "hidden_biases.blob"
-	outputWeightsBlob = "output_weights.blob"
-	outputBiasesBlob  = "output_biases.blob"
-)
+// Inputs returns the number of the input neurons.
+func (n *Network) Inputs() int {
+	return n.inputNeurons
+}
 
-// Save creates a tar file and saves the neural network to it.
-func (n Network) Save(filename string) error {
-	f, err := os.Create(filename) // nolint:gosec // user will provide the file.
-	if err != nil {
-		return fmt.Errorf("creating file: %w", err)
-	}
-	defer func() {
-		if err := f.Close(); err != nil {
-			config.Logger().Error("closing file", "error", err)
-		}
-	}()
+// Outputs returns the number of the output neurons.
+func (n *Network) Outputs() int {
+	_, c := n.layers[len(n.layers)-1].weights.Dims()
+	return c
+}
 
-	tw := tar.NewWriter(f)
-	defer func() {
-		if err := tw.Close(); err != nil {
-			config.Logger().Error("closing tar writer", "error", err)
-		}
-	}()
-
-	blobs := []struct {
-		matric *mat.Dense
-		name   string
-	}{
-		{n.hidden.weights, hiddenWeightsBlob},
-		{n.hidden.biases, hiddenBiasesBlob},
-		{n.output.weights, outputWeightsBlob},
-		{n.output.biases, outputBiasesBlob},
+// PredictBatch makes a prediction for each row of the input, which should
+// have a column for each input neuron. It returns a matrix with the outputs
+// of each row. Each layer is calculated with a single matrix multiplication
+// for all rows, therefore it is much faster than predicting the rows one by
+// one.
+func (n *Network) PredictBatch(input *mat.Dense) (*mat.Dense, error) {
+	if _, c := input.Dims(); c != n.inputNeurons {
+		return nil, fmt.Errorf("wrong input size: %d, want %d", c, n.inputNeurons)
 	}
-
-	for _, b := range blobs {
-		blobData, err := b.matric.MarshalBinary()
-		if err != nil {
-			return fmt.Errorf("marshalling %s: %w", b.name, err)
-		}
-		blobHdr := &tar.Header{
-			Name: b.name,
-			Size: int64(len(blobData)),
-		}
-		if err := tw.WriteHeader(blobHdr); err != nil {
-			return fmt.Errorf("writing %s header: %w", b.name, err)
-		}
-		if _, err := tw.Write(blobData); err != nil {
-			return fmt.Errorf("writing %s: %w", b.name, err)
+	m := input
+	for i := range n.layers {
+		l := &n.layers[i]
+		out := &mat.Dense{}
+		out.Mul(m, l.weights)
+		raw := out.RawMatrix()
+		biases := l.biases.RawRowView(0)
+		for r := 0; r < raw.Rows; r++ {
+			row := raw.Data[r*raw.Stride : r*raw.Stride+raw.Cols]
+			for j := range row {
+				row[j] = l.activation.Apply(row[j] + biases[j])
+			}
 		}
+		m = out
 	}
+	return m, nil
+}
 
-	if err := tw.Flush(); err != nil {
-		return fmt.Errorf("flushing tar writer: %w", err)
-	}
-
-	return nil
+// Workspace holds the intermediate values of a prediction, so they can be
+// reused. The zero value is ready to use, and the same workspace can be used
+// with networks of different shapes. It is not safe to use it concurrently.
+type Workspace struct {
+	current []float64
+	next    []float64
 }
 
-// Load reads a tar file and loads the neural network from it.
-func Load(filename string) (*Network, error) {
-	n := &Network{}
-	f, err := os.Open(filename) // nolint:gosec // user will provide the file.
-	if err != nil {
-		return nil, fmt.Errorf("opening file: %w", err)
+// PredictWith writes the output of the network for the input into dst. It
+// uses the workspace for the intermediate values, therefore it doesn't
+// allocate any memory once the workspace has grown to the size of the
+// largest layer.
+func (n *Network) PredictWith(w *Workspace, dst, input []float64) error {
+	if len(input) != n.inputNeurons {
+		return fmt.Errorf("wrong input size: %d, want %d", len(input), n.inputNeurons)
 	}
-	defer func() {
-		if err := f.Close(); err != nil {
-			config.Logger().Error("closing file", "error", err)
-		}
-	}()
-
-	tr := tar.NewReader(f)
-	for {
-		hdr, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, fmt.Errorf("reading tar header: %w", err)
-		}
-
-		switch hdr.Name {
-		case hiddenWeightsBlob:
-			hidWeights := &mat.Dense{}
-			if err := hidWeights.UnmarshalBinary(make([]byte, hdr.Size)); err != nil {
-				return nil, fmt.Errorf("unmarshalling hidden weights: %w", err)
-			}
-			n.hidden.weights = hidWeights
-		case hiddenBiasesBlob:
-			hidBiases := &mat.Dense{}
-			if err := hidBiases.UnmarshalBinary(make([]byte, hdr.Size)); err != nil {
-				return nil, fmt.Errorf("unmarshalling hidden biases: %w", err)
-			}
-			n.hidden.biases = hidBiases
-		case outputWeightsBlob:
-			outWeights := &mat.Dense{}
-			if err := outWeights.UnmarshalBinary(make([]byte, hdr.Size)); err != nil {
-				return nil, fmt.Errorf("unmarshalling output weights: %w", err)
+	if outputs := n.Outputs(); len(dst) != outputs {
+		return fmt.Errorf("wrong output size: %d, want %d", len(dst), outputs)
+	}
+	in := input
+	for i := range n.layers {
+		l := &n.layers[i]
+		weights := l.weights.RawMatrix()
+		out := dst
+		if i < len(n.layers)-1 {
+			if cap(w.next) < weights.Cols {
+				w.next = make([]float64, weights.Cols)
 			}
-			n.output.weights = outWeights
-		case outputBiasesBlob:
-			outBiases := &mat.Dense{}
-			if err := outBiases.UnmarshalBinary(make([]byte, hdr.Size)); err != nil {
-				return nil, fmt.Errorf("unmarshalling output biases: %w", err)
+			out = w.next[:weights.Cols]
+		}
+		copy(out, l.biases.RawRowView(0))
+		for r, v := range in {
+			row := weights.Data[r*weights.Stride : r*weights.Stride+weights.Cols]
+			for j, weight := range row {
+				out[j] += v * weight
 			}
-			n.output.biases = outBiases
-		default:
-			return nil, fmt.Errorf("unknown file: %s", hdr.Name)
 		}
+		for j := range out {
+			out[j] = l.activation.Apply(out[j])
+		}
+		in = out
+		w.current, w.next = w.next, w.current
 	}
-
-	return n, nil
+	return nil
 }
@@ -1,15 +1,22 @@
 package brain_test
 
 import (
+	"fmt"
+	stdrand "math/rand"
 	"testing"
 
+	"gonum.org/v1/gonum/mat"
+
 	"github.com/arsham/neuragene/internal/brain"
 )
 
-func BenchmarkNetwork(b *testing.B) {
+// benchmarkNetwork returns a network with 8 inputs, 6 hidden neurons and 10
+// outputs.
+func benchmarkNetwork(b *testing.B) *brain.Network {
+	b.Helper()
 	nn, err := brain.New(&brain.Config{
 		InputNeurons: 8,
-		HiddenLayer: brain.Layer{
+		Layers: []brain.Layer{{
 			Weights: []float64{
 				0.1, 0.2, 0.3, 0.4, 0.5, 0.6, -0.3, -0.2, // 1st neuron
 				0.1, 0.2, 0.3, 0.4, 0.5, 0.6, -0.3, -0.2, // 2nd neuron
@@ -18,9 +25,9 @@ func BenchmarkNetwork(b *testing.B) {
 				0.03, -0.04, 0.05, -0.06, 0.07, -0.08, 0.09, 0.1, // 5th neuron
 				0.1, -0.1, 0.1, -0.1, 0.1, -0.1, 0.1, 0.1, // 6th neuron
 			},
-			Biases: []float64{0.01, 0.02, 0.05, 0.01, -0.02, 0.05},
-		},
-		OutputLayer: brain.Layer{
+			Biases:     []float64{0.01, 0.02, 0.05, 0.01, -0.02, 0.05},
+			Activation: brain.Sigmoid,
+		}, {
 			Weights: []float64{
 				0.7, 0.8, 0.9, 1.0, 1.1, 1.2, // 1st neuron
 				6.7, -6.8, 6.9, 7.0, -7.1, 7.2, // 2nd neuron
@@ -33,14 +40,20 @@ func BenchmarkNetwork(b *testing.B) {
 				3.9, 4.0, 4.1, 4.2, 4.3, 4.4, // 9th neuron
 				4.5, 4.6, 4.7, 4.8, 4.9, -5.0, // 10th neuron
 			},
-			Biases: []float64{0.03, 0.04, 0.05, 0.06, 0.07, -0.8, 0.09, 0, 0.01, 0.02},
-		},
+			Biases:     []float64{0.03, 0.04, 0.05, 0.06, 0.07, -0.8, 0.09, 0, 0.01, 0.02},
+			Activation: brain.Sigmoid,
+		}},
 		OutputNeurons: 10,
 		TestCheck:     true,
 	})
 	if err != nil {
 		b.Fatal(err)
 	}
+	return nn
+}
+
+func BenchmarkNetwork(b *testing.B) {
+	nn := benchmarkNetwork(b)
 	input := []float64{0.1, 0.2, 0.3, -0.1, 0.15, 1, 0, 0.3}
 	b.ResetTimer()
 
@@ -51,3 +64,49 @@ func BenchmarkNetwork(b *testing.B) {
 		}
 	}
 }
+
+// BenchmarkNetworkEntities measures the prediction of all entities in a tick.
+func BenchmarkNetworkEntities(b *testing.B) {
+	nn := benchmarkNetwork(b)
+	for _, entities := range []int{1000, 10000} {
+		r := stdrand.New(stdrand.NewSource(1))
+		data := make([]float64, entities*nn.Inputs())
+		for i := range data {
+			data[i] = r.Float64()*2 - 1
+		}
+		input := mat.NewDense(entities, nn.Inputs(), data)
+
+		b.Run(fmt.Sprintf("Predict/%d", entities), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for e := 0; e < entities; e++ {
+					if _, err := nn.Predict(input.RawRowView(e)); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("Workspace/%d", entities), func(b *testing.B) {
+			ws := &brain.Workspace{}
+			dst := make([]float64, nn.Outputs())
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for e := 0; e < entities; e++ {
+					if err := nn.PredictWith(ws, dst, input.RawRowView(e)); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("Batch/%d", entities), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := nn.PredictBatch(input); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
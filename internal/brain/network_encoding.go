@@ -0,0 +1,259 @@
+package brain
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"gonum.org/v1/gonum/mat"
+
+	"github.com/arsham/neuragene/internal/config"
+)
+
+// networkFormatVersion is the version of the saved networks. It should be
+// increased when the format changes in a way that the older versions can't
+// load. The first version only allowed a hidden and an output layer, and it
+// can still be loaded.
+const (
+	networkFormatVersion    = 2
+	minNetworkFormatVersion = 1
+)
+
+const manifestFile = "manifest.json"
+
+// layerBlobs returns the names of the blobs of the i-th layer.
+func layerBlobs(i int) (weights, biases string) {
+	return fmt.Sprintf("layer_%d_weights.blob", i), fmt.Sprintf("layer_%d_biases.blob", i)
+}
+
+// ErrChecksum is returned when the content of a saved network doesn't match
+// its manifest.
+var ErrChecksum = errors.New("checksum mismatch")
+
+// networkManifest is the first entry of a saved network. It describes the
+// shape of the network and the blobs that hold its matrices.
+type networkManifest struct {
+	// Checksums holds the hex encoded SHA-256 sum of each blob.
+	Checksums map[string]string `json:"checksums"`
+	Layers    []layerManifest   `json:"layers"`
+	Version   int               `json:"version"`
+	Inputs    int               `json:"inputs"`
+	Outputs   int               `json:"outputs"`
+}
+
+type layerManifest struct {
+	Weights    string `json:"weights"`
+	Biases     string `json:"biases"`
+	Activation string `json:"activation"`
+	Neurons    int    `json:"neurons"`
+}
+
+// Encode writes the network to w as a tar archive. The first entry is a JSON
+// manifest with the format version, the shape of the layers, their
+// activations and the checksums of the blobs that follow it.
+func (n *Network) Encode(w io.Writer) error {
+	m := networkManifest{
+		Version:   networkFormatVersion,
+		Inputs:    n.inputNeurons,
+		Checksums: make(map[string]string, len(n.layers)*2),
+	}
+	type blob struct {
+		name string
+		data []byte
+	}
+	blobs := make([]blob, 0, len(n.layers)*2)
+	for i := range n.layers {
+		l := &n.layers[i]
+		weights, biases := layerBlobs(i)
+		_, neurons := l.weights.Dims()
+		m.Layers = append(m.Layers, layerManifest{
+			Weights:    weights,
+			Biases:     biases,
+			Activation: l.activation.String(),
+			Neurons:    neurons,
+		})
+		m.Outputs = neurons
+		for _, b := range []struct {
+			matrix *mat.Dense
+			name   string
+		}{
+			{l.weights, weights},
+			{l.biases, biases},
+		} {
+			data, err := b.matrix.MarshalBinary()
+			if err != nil {
+				return fmt.Errorf("marshalling %s: %w", b.name, err)
+			}
+			sum := sha256.Sum256(data)
+			m.Checksums[b.name] = hex.EncodeToString(sum[:])
+			blobs = append(blobs, blob{name: b.name, data: data})
+		}
+	}
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling manifest: %w", err)
+	}
+	blobs = append([]blob{{name: manifestFile, data: manifest}}, blobs...)
+
+	tw := tar.NewWriter(w)
+	for _, b := range blobs {
+		hdr := &tar.Header{
+			Name: b.name,
+			Mode: 0o644,
+			Size: int64(len(b.data)),
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("writing %s header: %w", b.name, err)
+		}
+		if _, err := tw.Write(b.data); err != nil {
+			return fmt.Errorf("writing %s: %w", b.name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("closing tar writer: %w", err)
+	}
+	return nil
+}
+
+// Decode reads a network from r that was written by the Encode method. It
+// verifies the format version, the checksums and the shapes of all matrices.
+func Decode(r io.Reader) (*Network, error) {
+	tr := tar.NewReader(r)
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading manifest header: %w", ErrInvalidFormat, err)
+	}
+	if hdr.Name != manifestFile {
+		return nil, fmt.Errorf("%w: first entry is %s, want %s", ErrInvalidFormat, hdr.Name, manifestFile)
+	}
+	var m networkManifest
+	if err := json.NewDecoder(tr).Decode(&m); err != nil {
+		return nil, fmt.Errorf("%w: decoding manifest: %w", ErrInvalidFormat, err)
+	}
+	if m.Version < minNetworkFormatVersion || m.Version > networkFormatVersion {
+		return nil, fmt.Errorf("%w: version %d, want %d to %d",
+			ErrInvalidFormat, m.Version, minNetworkFormatVersion, networkFormatVersion)
+	}
+	if len(m.Layers) == 0 || m.Version == 1 && len(m.Layers) != 2 {
+		return nil, fmt.Errorf("%w: %d layers in version %d", ErrInvalidFormat, len(m.Layers), m.Version)
+	}
+
+	blobs := make(map[string][]byte, len(m.Checksums))
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: reading tar header: %w", ErrInvalidFormat, err)
+		}
+		want, ok := m.Checksums[hdr.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown file: %s", ErrInvalidFormat, hdr.Name)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: reading %s: %w", ErrInvalidFormat, hdr.Name, err)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != want {
+			return nil, fmt.Errorf("%w: %s", ErrChecksum, hdr.Name)
+		}
+		blobs[hdr.Name] = data
+	}
+
+	n := &Network{
+		inputNeurons: m.Inputs,
+		layers:       make([]layer, len(m.Layers)),
+	}
+	rows := m.Inputs
+	for i, lm := range m.Layers {
+		l := &n.layers[i]
+		activation, err := ParseActivation(lm.Activation)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+		}
+		l.activation = activation
+		if l.weights, err = decodeMatrix(blobs, lm.Weights, rows, lm.Neurons); err != nil {
+			return nil, err
+		}
+		if l.biases, err = decodeMatrix(blobs, lm.Biases, 1, lm.Neurons); err != nil {
+			return nil, err
+		}
+		rows = lm.Neurons
+	}
+	if rows != m.Outputs {
+		return nil, fmt.Errorf("%w: %d output neurons, want %d", ErrInvalidFormat, rows, m.Outputs)
+	}
+	return n, nil
+}
+
+// decodeMatrix returns the matrix stored in the named blob, and makes sure it
+// has the given shape.
+func decodeMatrix(blobs map[string][]byte, name string, rows, cols int) (*mat.Dense, error) {
+	data, ok := blobs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidFormat, name)
+	}
+	m := &mat.Dense{}
+	if err := m.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("%w: unmarshalling %s: %w", ErrInvalidFormat, name, err)
+	}
+	if r, c := m.Dims(); r != rows || c != cols {
+		return nil, fmt.Errorf("%w: %s is %dx%d, want %dx%d", ErrInvalidFormat, name, r, c, rows, cols)
+	}
+	return m, nil
+}
+
+// Save creates a tar file and saves the neural network to it.
+func (n *Network) Save(filename string) error {
+	f, err := os.Create(filename) // nolint:gosec // user will provide the file.
+	if err != nil {
+		return fmt.Errorf("creating file: %w", err)
+	}
+	if err := n.Encode(f); err != nil {
+		if err := f.Close(); err != nil {
+			config.Logger().Error("closing file", "error", err)
+		}
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing file: %w", err)
+	}
+	return nil
+}
+
+// Load reads a tar file and loads the neural network from it.
+func Load(filename string) (*Network, error) {
+	f, err := os.Open(filename) // nolint:gosec // user will provide the file.
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			config.Logger().Error("closing file", "error", err)
+		}
+	}()
+	return Decode(f)
+}
+
+// LoadFS loads the neural network from the named file in fsys. It can be used
+// to load the networks that are embedded in the binary.
+func LoadFS(fsys fs.FS, name string) (*Network, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			config.Logger().Error("closing file", "error", err)
+		}
+	}()
+	return Decode(f)
+}
@@ -0,0 +1,307 @@
+package brain
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestNetworkEncoding(t *testing.T) {
+	t.Parallel()
+	t.Run("RoundTrip", testNetworkEncodingRoundTrip)
+	t.Run("Manifest", testNetworkEncodingManifest)
+	t.Run("DeepNetwork", testNetworkEncodingDeepNetwork)
+	t.Run("Version1", testNetworkEncodingVersion1)
+	t.Run("SaveLoad", testNetworkEncodingSaveLoad)
+	t.Run("LoadFS", testNetworkEncodingLoadFS)
+	t.Run("Invalid", testNetworkEncodingInvalid)
+}
+
+func testNetwork(t *testing.T) *Network {
+	t.Helper()
+	nn, err := New(&Config{
+		InputNeurons: 4,
+		HiddenLayer: Layer{
+			Weights: []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.3, 0.2, 0.1, 0.2, 0.3, 0.4},
+			Biases:  []float64{0.1, 0.2, 0.5},
+		},
+		OutputLayer: Layer{
+			Weights: []float64{0.7, 0.8, 0.9, 1.0, 1.1, 1.2},
+			Biases:  []float64{0.3, 0.4},
+		},
+		OutputNeurons: 2,
+		TestCheck:     true,
+	})
+	assert.NoError(t, err)
+	return nn
+}
+
+// assertSameNetworkPredictions checks that both networks produce the same outputs.
+func assertSameNetworkPredictions(t *testing.T, want, got *Network) {
+	t.Helper()
+	for _, input := range [][]float64{{0.1, 0.2, 0.3, 0.1}, {0.9, -0.8, 0.7, 0.5}} {
+		a, err := want.Predict(input)
+		assert.NoError(t, err)
+		b, err := got.Predict(input)
+		assert.NoError(t, err)
+		assert.Equal(t, a, b)
+	}
+}
+
+// tarEntries returns the names and contents of the entries of a tar archive.
+func tarEntries(t *testing.T, data []byte) ([]string, map[string][]byte) {
+	t.Helper()
+	var names []string
+	contents := make(map[string][]byte)
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		content, err := io.ReadAll(tr)
+		assert.NoError(t, err)
+		names = append(names, hdr.Name)
+		contents[hdr.Name] = content
+	}
+	return names, contents
+}
+
+// writeTar returns a tar archive with the given entries in order.
+func writeTar(t *testing.T, names []string, contents map[string][]byte) []byte {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for _, name := range names {
+		err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(contents[name]))})
+		assert.NoError(t, err)
+		_, err = tw.Write(contents[name])
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func testNetworkEncodingRoundTrip(t *testing.T) {
+	t.Parallel()
+	nn := testNetwork(t)
+	buf := &bytes.Buffer{}
+	err := nn.Encode(buf)
+	assert.NoError(t, err)
+
+	got, err := Decode(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	assertSameNetworkPredictions(t, nn, got)
+
+	again := &bytes.Buffer{}
+	err = got.Encode(again)
+	assert.NoError(t, err)
+	assert.Equal(t, buf.Bytes(), again.Bytes())
+}
+
+func testNetworkEncodingManifest(t *testing.T) {
+	t.Parallel()
+	buf := &bytes.Buffer{}
+	err := testNetwork(t).Encode(buf)
+	assert.NoError(t, err)
+
+	names, contents := tarEntries(t, buf.Bytes())
+	assert.Equal(t, []string{
+		manifestFile,
+		"layer_0_weights.blob", "layer_0_biases.blob",
+		"layer_1_weights.blob", "layer_1_biases.blob",
+	}, names)
+	var m networkManifest
+	err = json.Unmarshal(contents[manifestFile], &m)
+	assert.NoError(t, err)
+	assert.Equal(t, networkFormatVersion, m.Version)
+	assert.Equal(t, 4, m.Inputs)
+	assert.Equal(t, 2, m.Outputs)
+	assert.Equal(t, []layerManifest{
+		{Weights: "layer_0_weights.blob", Biases: "layer_0_biases.blob", Activation: "sigmoid", Neurons: 3},
+		{Weights: "layer_1_weights.blob", Biases: "layer_1_biases.blob", Activation: "sigmoid", Neurons: 2},
+	}, m.Layers)
+	assert.Equal(t, 4, len(m.Checksums))
+}
+
+func testNetworkEncodingDeepNetwork(t *testing.T) {
+	t.Parallel()
+	nn := deepNetwork(t)
+	buf := &bytes.Buffer{}
+	err := nn.Encode(buf)
+	assert.NoError(t, err)
+
+	got, err := Decode(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, len(nn.layers), len(got.layers))
+	for i := range nn.layers {
+		assert.Equal(t, nn.layers[i].activation, got.layers[i].activation)
+	}
+	input := []float64{0.5, -0.5, 1}
+	want, err := nn.Predict(input)
+	assert.NoError(t, err)
+	output, err := got.Predict(input)
+	assert.NoError(t, err)
+	assert.Equal(t, want, output)
+}
+
+// testNetworkEncodingVersion1 makes sure the networks saved with the first
+// version, which had fixed names for the hidden and output layers, can be
+// loaded.
+func testNetworkEncodingVersion1(t *testing.T) {
+	t.Parallel()
+	nn := testNetwork(t)
+	buf := &bytes.Buffer{}
+	err := nn.Encode(buf)
+	assert.NoError(t, err)
+	_, contents := tarEntries(t, buf.Bytes())
+
+	legacy := map[string]string{
+		"layer_0_weights.blob": "hidden_weights.blob",
+		"layer_0_biases.blob":  "hidden_biases.blob",
+		"layer_1_weights.blob": "output_weights.blob",
+		"layer_1_biases.blob":  "output_biases.blob",
+	}
+	var m networkManifest
+	assert.NoError(t, json.Unmarshal(contents[manifestFile], &m))
+	m.Version = 1
+	for i := range m.Layers {
+		m.Layers[i].Weights = legacy[m.Layers[i].Weights]
+		m.Layers[i].Biases = legacy[m.Layers[i].Biases]
+	}
+	checksums := make(map[string]string, len(m.Checksums))
+	names := []string{manifestFile}
+	for name, sum := range m.Checksums {
+		checksums[legacy[name]] = sum
+		contents[legacy[name]] = contents[name]
+	}
+	for _, name := range []string{"layer_0_weights.blob", "layer_0_biases.blob", "layer_1_weights.blob", "layer_1_biases.blob"} {
+		names = append(names, legacy[name])
+	}
+	m.Checksums = checksums
+	contents[manifestFile], err = json.Marshal(m)
+	assert.NoError(t, err)
+
+	got, err := Decode(bytes.NewReader(writeTar(t, names, contents)))
+	assert.NoError(t, err)
+	assertSameNetworkPredictions(t, nn, got)
+}
+
+func testNetworkEncodingSaveLoad(t *testing.T) {
+	t.Parallel()
+	nn := testNetwork(t)
+	name := filepath.Join(t.TempDir(), "network.tar")
+	err := nn.Save(name)
+	assert.NoError(t, err)
+
+	got, err := Load(name)
+	assert.NoError(t, err)
+	assertSameNetworkPredictions(t, nn, got)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.tar"))
+	assert.Error(t, err)
+}
+
+func testNetworkEncodingLoadFS(t *testing.T) {
+	t.Parallel()
+	nn := testNetwork(t)
+	buf := &bytes.Buffer{}
+	err := nn.Encode(buf)
+	assert.NoError(t, err)
+	fsys := fstest.MapFS{
+		"networks/baseline.tar": &fstest.MapFile{Data: buf.Bytes()},
+	}
+
+	got, err := LoadFS(fsys, "networks/baseline.tar")
+	assert.NoError(t, err)
+	assertSameNetworkPredictions(t, nn, got)
+
+	_, err = LoadFS(fsys, "networks/missing.tar")
+	assert.Error(t, err)
+}
+
+func testNetworkEncodingInvalid(t *testing.T) {
+	t.Parallel()
+	buf := &bytes.Buffer{}
+	err := testNetwork(t).Encode(buf)
+	assert.NoError(t, err)
+	names, contents := tarEntries(t, buf.Bytes())
+
+	// The cases get the subtest's t, since they run in parallel subtests.
+	manifest := func(fn func(m *networkManifest)) func(*testing.T, []string, map[string][]byte) []string {
+		return func(t *testing.T, names []string, contents map[string][]byte) []string {
+			var m networkManifest
+			assert.NoError(t, json.Unmarshal(contents[manifestFile], &m))
+			fn(&m)
+			data, err := json.Marshal(m)
+			assert.NoError(t, err)
+			contents[manifestFile] = data
+			return names
+		}
+	}
+	tcs := map[string]struct {
+		fn   func(*testing.T, []string, map[string][]byte) []string
+		want error
+	}{
+		"checksum": {func(_ *testing.T, names []string, contents map[string][]byte) []string {
+			contents["layer_0_biases.blob"][len(contents["layer_0_biases.blob"])-1]++
+			return names
+		}, ErrChecksum},
+		"no manifest": {func(_ *testing.T, names []string, _ map[string][]byte) []string {
+			return names[1:]
+		}, ErrInvalidFormat},
+		"missing blob": {func(_ *testing.T, names []string, _ map[string][]byte) []string {
+			return names[:len(names)-1]
+		}, ErrInvalidFormat},
+		"unknown file": {func(_ *testing.T, names []string, contents map[string][]byte) []string {
+			contents["extra.blob"] = []byte("extra")
+			return append(names, "extra.blob")
+		}, ErrInvalidFormat},
+		"version": {manifest(func(m *networkManifest) {
+			m.Version++
+		}), ErrInvalidFormat},
+		"activation": {manifest(func(m *networkManifest) {
+			m.Layers[0].Activation = "foo"
+		}), ErrInvalidFormat},
+		"shape": {manifest(func(m *networkManifest) {
+			m.Inputs = 3
+		}), ErrInvalidFormat},
+		"outputs": {manifest(func(m *networkManifest) {
+			m.Outputs = 3
+		}), ErrInvalidFormat},
+		"layers": {manifest(func(m *networkManifest) {
+			m.Layers = m.Layers[:1]
+		}), ErrInvalidFormat},
+		"no layers": {manifest(func(m *networkManifest) {
+			m.Layers = nil
+		}), ErrInvalidFormat},
+		"version 1 layers": {manifest(func(m *networkManifest) {
+			m.Version = 1
+			m.Layers = append(m.Layers, m.Layers[1])
+		}), ErrInvalidFormat},
+	}
+	for name, tc := range tcs {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c := make(map[string][]byte, len(contents))
+			for k, v := range contents {
+				c[k] = bytes.Clone(v)
+			}
+			data := writeTar(t, tc.fn(t, slices.Clone(names), c), c)
+			_, err := Decode(bytes.NewReader(data))
+			assert.IsError(t, err, tc.want)
+		})
+	}
+
+	_, err = Decode(bytes.NewReader([]byte("not a tar file")))
+	assert.IsError(t, err, ErrInvalidFormat)
+}
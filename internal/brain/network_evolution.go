@@ -0,0 +1,172 @@
+package brain
+
+import (
+	"errors"
+	"fmt"
+	stdrand "math/rand"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// MutationConfig is the configuration of the Network.Mutate method. The Rate
+// is used as it is, therefore you should start from the DefaultMutationConfig
+// to get its default. Any zero values of the sigmas are replaced with the
+// defaults.
+type MutationConfig struct {
+	// Rate is the chance of each parameter being perturbed, in the [0, 1]
+	// range. Zero disables the mutation. The default value is 0.1.
+	Rate float64
+	// WeightSigma is the standard deviation of the normal noise that is added
+	// to the weights. The default value is 0.1.
+	WeightSigma float64
+	// BiasSigma is the standard deviation of the normal noise that is added
+	// to the biases. The default value is 0.1.
+	BiasSigma float64
+}
+
+// DefaultMutationConfig returns a MutationConfig with the default values.
+func DefaultMutationConfig() *MutationConfig {
+	return &MutationConfig{
+		Rate:        0.1,
+		WeightSigma: 0.1,
+		BiasSigma:   0.1,
+	}
+}
+
+func (c *MutationConfig) setDefaults() {
+	if c.WeightSigma == 0 {
+		c.WeightSigma = 0.1
+	}
+	if c.BiasSigma == 0 {
+		c.BiasSigma = 0.1
+	}
+}
+
+// ErrShapeMismatch is returned when two networks with different shapes are
+// bred.
+var ErrShapeMismatch = errors.New("shape mismatch")
+
+// Clone returns a deep copy of the network.
+func (n *Network) Clone() *Network {
+	clone := &Network{
+		inputNeurons: n.inputNeurons,
+		layers:       make([]layer, len(n.layers)),
+	}
+	for i := range n.layers {
+		l := &n.layers[i]
+		clone.layers[i] = layer{
+			weights:    mat.DenseCopyOf(l.weights),
+			biases:     mat.DenseCopyOf(l.biases),
+			activation: l.activation,
+		}
+	}
+	return clone
+}
+
+// Parameters returns the number of the weights and biases of the network.
+func (n *Network) Parameters() int {
+	var total int
+	for _, p := range n.parameters() {
+		total += len(p)
+	}
+	return total
+}
+
+// parameters returns the weights and then the biases of each layer. The
+// slices share the memory of the network, therefore they can be used for
+// updating the network in place. Flattening them in this order gives the
+// genome of the network.
+func (n *Network) parameters() [][]float64 {
+	ret := make([][]float64, 0, len(n.layers)*2)
+	for i := range n.layers {
+		ret = append(ret,
+			n.layers[i].weights.RawMatrix().Data,
+			n.layers[i].biases.RawMatrix().Data,
+		)
+	}
+	return ret
+}
+
+// Mutate perturbs the weights and biases of the network in place. Each
+// parameter is changed with the chance of the configured rate by adding a
+// normally distributed noise. It returns the same network.
+func (n *Network) Mutate(c *MutationConfig, rand *stdrand.Rand) *Network {
+	config := *c
+	config.setDefaults()
+	for i, p := range n.parameters() {
+		sigma := config.WeightSigma
+		if i%2 == 1 {
+			sigma = config.BiasSigma
+		}
+		for j := range p {
+			if rand.Float64() < config.Rate {
+				p[j] += rand.NormFloat64() * sigma
+			}
+		}
+	}
+	return n
+}
+
+// sameShape returns an error if the two networks don't have the same inputs,
+// layer sizes and activations.
+func sameShape(a, b *Network) error {
+	if a.inputNeurons != b.inputNeurons || len(a.layers) != len(b.layers) {
+		return fmt.Errorf("%w: %d inputs and %d layers, want %d and %d",
+			ErrShapeMismatch, b.inputNeurons, len(b.layers), a.inputNeurons, len(a.layers))
+	}
+	for i := range a.layers {
+		_, ca := a.layers[i].weights.Dims()
+		_, cb := b.layers[i].weights.Dims()
+		if ca != cb {
+			return fmt.Errorf("%w: layer %d has %d neurons, want %d", ErrShapeMismatch, i, cb, ca)
+		}
+		if a.layers[i].activation != b.layers[i].activation {
+			return fmt.Errorf("%w: layer %d has %s activation, want %s",
+				ErrShapeMismatch, i, b.layers[i].activation, a.layers[i].activation)
+		}
+	}
+	return nil
+}
+
+// UniformCrossover breeds the two networks and returns a new child. Each
+// weight and bias of the child is inherited randomly from either parent. The
+// networks should have the same shape.
+func UniformCrossover(a, b *Network, rand *stdrand.Rand) (*Network, error) {
+	if err := sameShape(a, b); err != nil {
+		return nil, err
+	}
+	child := a.Clone()
+	other := b.parameters()
+	for i, p := range child.parameters() {
+		for j := range p {
+			if rand.Intn(2) == 0 {
+				p[j] = other[i][j]
+			}
+		}
+	}
+	return child, nil
+}
+
+// SinglePointCrossover breeds the two networks and returns a new child. The
+// parameters of both networks are flattened in the order of the layers, with
+// the weights of each layer before its biases. The child inherits the
+// parameters before a random point from the first parent, and the rest from
+// the second parent. The networks should have the same shape.
+func SinglePointCrossover(a, b *Network, rand *stdrand.Rand) (*Network, error) {
+	if err := sameShape(a, b); err != nil {
+		return nil, err
+	}
+	child := a.Clone()
+	point := rand.Intn(child.Parameters() + 1)
+	other := b.parameters()
+	var index int
+	for i, p := range child.parameters() {
+		for j := range p {
+			if index >= point {
+				p[j] = other[i][j]
+			}
+			index++
+		}
+	}
+	return child, nil
+}
@@ -0,0 +1,145 @@
+package brain
+
+import (
+	"errors"
+	stdrand "math/rand"
+	"slices"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestNetworkEvolution(t *testing.T) {
+	t.Parallel()
+	t.Run("Clone", testNetworkClone)
+	t.Run("Mutate", testNetworkMutate)
+	t.Run("UniformCrossover", testNetworkUniformCrossover)
+	t.Run("SinglePointCrossover", testNetworkSinglePointCrossover)
+	t.Run("ShapeMismatch", testNetworkShapeMismatch)
+}
+
+// flatten returns a copy of all parameters of the network in the genome order.
+func flatten(n *Network) []float64 {
+	var ret []float64
+	for _, p := range n.parameters() {
+		ret = append(ret, p...)
+	}
+	return ret
+}
+
+func testNetworkClone(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	nn := randomNetwork(t, r, 3, []int{4, 2}, []Activation{Tanh, Sigmoid})
+	clone := nn.Clone()
+	assert.Equal(t, flatten(nn), flatten(clone))
+	assert.Equal(t, 3*4+4+4*2+2, clone.Parameters())
+
+	input := []float64{0.1, -0.4, 0.9}
+	want, err := nn.Predict(input)
+	assert.NoError(t, err)
+	got, err := clone.Predict(input)
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	clone.parameters()[0][0] += 1
+	assert.NotEqual(t, flatten(nn), flatten(clone), "the clone should not share memory")
+}
+
+func testNetworkMutate(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	nn := randomNetwork(t, r, 5, []int{6, 3}, []Activation{Tanh, Sigmoid})
+	before := flatten(nn)
+
+	got := nn.Mutate(&MutationConfig{Rate: 1, WeightSigma: 0.5}, r)
+	assert.True(t, got == nn, "it should mutate in place")
+	after := flatten(nn)
+	for i := range before {
+		assert.NotEqual(t, before[i], after[i], "parameter %d", i)
+	}
+
+	before = after
+	nn.Mutate(&MutationConfig{Rate: 0.2}, r)
+	after = flatten(nn)
+	var changed int
+	for i := range before {
+		if before[i] != after[i] {
+			changed++
+		}
+	}
+	assert.True(t, changed > 0 && changed < len(before), "changed %d of %d", changed, len(before))
+
+	// A zero rate disables the mutation.
+	before = after
+	c := DefaultMutationConfig()
+	assert.Equal(t, 0.1, c.Rate)
+	c.Rate = 0
+	nn.Mutate(c, r)
+	assert.Equal(t, before, flatten(nn))
+}
+
+func testNetworkUniformCrossover(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	a := randomNetwork(t, r, 4, []int{5, 2}, []Activation{Tanh, Sigmoid})
+	b := randomNetwork(t, r, 4, []int{5, 2}, []Activation{Tanh, Sigmoid})
+	pa, pb := flatten(a), flatten(b)
+
+	child, err := UniformCrossover(a, b, r)
+	assert.NoError(t, err)
+	var fromA, fromB int
+	for i, v := range flatten(child) {
+		switch v {
+		case pa[i]:
+			fromA++
+		case pb[i]:
+			fromB++
+		default:
+			t.Fatalf("parameter %d is from neither parent", i)
+		}
+	}
+	assert.True(t, fromA > 0 && fromB > 0, "from a %d, from b %d", fromA, fromB)
+	assert.Equal(t, pa, flatten(a), "the parents should not change")
+	assert.Equal(t, pb, flatten(b), "the parents should not change")
+}
+
+func testNetworkSinglePointCrossover(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	a := randomNetwork(t, r, 4, []int{5, 2}, []Activation{Tanh, Sigmoid})
+	b := randomNetwork(t, r, 4, []int{5, 2}, []Activation{Tanh, Sigmoid})
+	pa, pb := flatten(a), flatten(b)
+
+	for i := 0; i < 20; i++ {
+		child, err := SinglePointCrossover(a, b, r)
+		assert.NoError(t, err)
+		got := flatten(child)
+		point := slices.IndexFunc(got, func(v float64) bool {
+			return slices.Contains(pb, v)
+		})
+		if point < 0 {
+			point = len(got)
+		}
+		assert.Equal(t, pa[:point], got[:point])
+		assert.Equal(t, pb[point:], got[point:])
+	}
+}
+
+func testNetworkShapeMismatch(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	a := randomNetwork(t, r, 4, []int{5, 2}, []Activation{Tanh, Sigmoid})
+	tcs := map[string]*Network{
+		"inputs":     randomNetwork(t, r, 3, []int{5, 2}, []Activation{Tanh, Sigmoid}),
+		"layers":     randomNetwork(t, r, 4, []int{5, 5, 2}, []Activation{Tanh, Tanh, Sigmoid}),
+		"neurons":    randomNetwork(t, r, 4, []int{6, 2}, []Activation{Tanh, Sigmoid}),
+		"activation": randomNetwork(t, r, 4, []int{5, 2}, []Activation{ReLU, Sigmoid}),
+	}
+	for name, b := range tcs {
+		_, err := UniformCrossover(a, b, r)
+		assert.True(t, errors.Is(err, ErrShapeMismatch), name)
+		_, err = SinglePointCrossover(a, b, r)
+		assert.True(t, errors.Is(err, ErrShapeMismatch), name)
+	}
+}
@@ -3,10 +3,12 @@ package brain
 import (
 	"fmt"
 	"math"
+	stdrand "math/rand"
 	"slices"
 	"testing"
 
 	"github.com/alecthomas/assert/v2"
+	"gonum.org/v1/gonum/mat"
 )
 
 func TestPredictTableDriven(t *testing.T) {
@@ -131,3 +133,243 @@ func testPredictTableDriven8x6x10(t *testing.T) {
 		})
 	}
 }
+
+func TestNetworkLayers(t *testing.T) {
+	t.Parallel()
+	t.Run("Legacy", testNetworkLayersLegacy)
+	t.Run("Deep", testNetworkLayersDeep)
+	t.Run("Validation", testNetworkLayersValidation)
+}
+
+// deepNetwork returns a network with 3 inputs, three hidden layers of 4, 3
+// and 2 neurons, and one output.
+func deepNetwork(t *testing.T) *Network {
+	t.Helper()
+	nn, err := New(&Config{
+		InputNeurons: 3,
+		Layers: []Layer{
+			{
+				Weights: []float64{
+					0.1, -0.2, 0.3, 0.4,
+					-0.5, 0.6, 0.7, -0.8,
+					0.9, 0.1, -0.2, 0.3,
+				},
+				Biases:     []float64{0.1, 0.2, -0.1, 0},
+				Activation: ReLU,
+			},
+			{
+				Weights: []float64{
+					0.2, -0.3, 0.4,
+					0.5, 0.1, -0.6,
+					-0.7, 0.8, 0.2,
+					0.3, 0.3, 0.3,
+				},
+				Biases:     []float64{0, -0.1, 0.1},
+				Activation: Tanh,
+			},
+			{
+				Weights:    []float64{1, -1, 0.5, 0.5, -0.5, 1},
+				Biases:     []float64{0.05, -0.05},
+				Activation: LeakyReLU,
+			},
+			{
+				Weights:    []float64{0.8, -1.2},
+				Biases:     []float64{0.1},
+				Activation: Sigmoid,
+			},
+		},
+		TestCheck: true,
+	})
+	assert.NoError(t, err)
+	return nn
+}
+
+func testNetworkLayersLegacy(t *testing.T) {
+	t.Parallel()
+	hidden := Layer{
+		Weights: []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.3, 0.2, 0.1, 0.2, 0.3, 0.4},
+		Biases:  []float64{0.1, 0.2, 0.5},
+	}
+	output := Layer{
+		Weights: []float64{0.7, 0.8, 0.9, 1.0, 1.1, 1.2},
+		Biases:  []float64{0.3, 0.4},
+	}
+	legacy, err := New(&Config{
+		InputNeurons:  4,
+		HiddenLayer:   hidden,
+		OutputLayer:   output,
+		OutputNeurons: 2,
+		TestCheck:     true,
+	})
+	assert.NoError(t, err)
+
+	hidden.Activation = Sigmoid
+	output.Activation = Sigmoid
+	layered, err := New(&Config{
+		InputNeurons: 4,
+		Layers:       []Layer{hidden, output},
+		TestCheck:    true,
+	})
+	assert.NoError(t, err)
+
+	input := []float64{0.1, 0.2, 0.3, 0.1}
+	want, err := legacy.Predict(input)
+	assert.NoError(t, err)
+	got, err := layered.Predict(input)
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func testNetworkLayersDeep(t *testing.T) {
+	t.Parallel()
+	nn := deepNetwork(t)
+	input := []float64{0.5, -0.5, 1}
+
+	// The expected value is calculated by hand, one neuron at a time.
+	forward := func(input []float64, l Layer) []float64 {
+		out := make([]float64, len(l.Biases))
+		for j := range out {
+			sum := l.Biases[j]
+			for i := range input {
+				sum += input[i] * l.Weights[i*len(out)+j]
+			}
+			out[j] = l.Activation.Apply(sum)
+		}
+		return out
+	}
+	want := input
+	for i := range nn.layers {
+		l := nn.layers[i]
+		want = forward(want, Layer{
+			Weights:    l.weights.RawMatrix().Data,
+			Biases:     l.biases.RawMatrix().Data,
+			Activation: l.activation,
+		})
+	}
+
+	got, err := nn.Predict(input)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(got))
+	assert.True(t, almostEqual(want, got, 1e-12), "want %v, got %v", want, got)
+
+	_, err = nn.Predict([]float64{1, 2})
+	assert.Error(t, err)
+}
+
+func testNetworkLayersValidation(t *testing.T) {
+	t.Parallel()
+	valid := func() *Config {
+		return &Config{
+			InputNeurons: 2,
+			Layers: []Layer{
+				{Weights: []float64{1, 2, 3, 4, 5, 6}, Biases: []float64{1, 2, 3}, Activation: ReLU},
+				{Weights: []float64{1, 2, 3}, Biases: []float64{1}, Activation: Sigmoid},
+			},
+			OutputNeurons: 1,
+			TestCheck:     true,
+		}
+	}
+	_, err := New(valid())
+	assert.NoError(t, err)
+
+	tcs := map[string]func(c *Config){
+		"inputs":           func(c *Config) { c.InputNeurons = 0 },
+		"empty layer":      func(c *Config) { c.Layers[1] = Layer{} },
+		"weights":          func(c *Config) { c.Layers[1].Weights = c.Layers[1].Weights[:2] },
+		"first layer":      func(c *Config) { c.InputNeurons = 3 },
+		"activation":       func(c *Config) { c.Layers[0].Activation = Activation(200) },
+		"output neurons":   func(c *Config) { c.OutputNeurons = 2 },
+		"legacy outputs":   func(c *Config) { c.Layers = nil; c.OutputNeurons = 0 },
+		"legacy no layers": func(c *Config) { c.Layers = nil },
+	}
+	for name, fn := range tcs {
+		fn := fn
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			c := valid()
+			fn(c)
+			_, err := New(c)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNetworkBatch(t *testing.T) {
+	t.Parallel()
+	t.Run("PredictBatch", testNetworkBatchPredictBatch)
+	t.Run("Workspace", testNetworkBatchWorkspace)
+	t.Run("Errors", testNetworkBatchErrors)
+}
+
+// randomInputs returns rows of random inputs for the network.
+func randomInputs(r *stdrand.Rand, rows, cols int) *mat.Dense {
+	data := make([]float64, rows*cols)
+	for i := range data {
+		data[i] = r.Float64()*4 - 2
+	}
+	return mat.NewDense(rows, cols, data)
+}
+
+func testNetworkBatchPredictBatch(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	nn := deepNetwork(t)
+	input := randomInputs(r, 50, nn.Inputs())
+
+	got, err := nn.PredictBatch(input)
+	assert.NoError(t, err)
+	rows, cols := got.Dims()
+	assert.Equal(t, 50, rows)
+	assert.Equal(t, nn.Outputs(), cols)
+	for i := 0; i < rows; i++ {
+		want, err := nn.Predict(input.RawRowView(i))
+		assert.NoError(t, err)
+		assert.True(t, almostEqual(want, got.RawRowView(i), 1e-12), "row %d: want %v, got %v", i, want, got.RawRowView(i))
+	}
+}
+
+func testNetworkBatchWorkspace(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(2))
+	deep := deepNetwork(t)
+	wide := testNetwork(t)
+	// The same workspace is shared between networks of different shapes.
+	ws := &Workspace{}
+	for _, nn := range []*Network{deep, wide, deep} {
+		input := randomInputs(r, 10, nn.Inputs())
+		dst := make([]float64, nn.Outputs())
+		for i := 0; i < 10; i++ {
+			want, err := nn.Predict(input.RawRowView(i))
+			assert.NoError(t, err)
+			err = nn.PredictWith(ws, dst, input.RawRowView(i))
+			assert.NoError(t, err)
+			assert.True(t, almostEqual(want, dst, 1e-12), "want %v, got %v", want, dst)
+		}
+	}
+}
+
+func testNetworkBatchErrors(t *testing.T) {
+	t.Parallel()
+	nn := deepNetwork(t)
+	_, err := nn.PredictBatch(mat.NewDense(2, 2, nil))
+	assert.Error(t, err)
+
+	ws := &Workspace{}
+	err = nn.PredictWith(ws, make([]float64, 1), []float64{1, 2})
+	assert.Error(t, err)
+	err = nn.PredictWith(ws, make([]float64, 2), []float64{1, 2, 3})
+	assert.Error(t, err)
+}
+
+// TestNetworkAllocations can't run in parallel with other tests, otherwise
+// AllocsPerRun panics.
+func TestNetworkAllocations(t *testing.T) {
+	nn := deepNetwork(t)
+	input := []float64{1, 2, 3}
+	dst := make([]float64, nn.Outputs())
+	ws := &Workspace{}
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = nn.PredictWith(ws, dst, input)
+	})
+	assert.Equal(t, 0.0, allocs)
+}
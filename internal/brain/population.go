@@ -0,0 +1,333 @@
+package brain
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	stdrand "math/rand"
+	"slices"
+)
+
+// PopulationConfig is the configuration of a Population. The Elitism,
+// SurvivalRate and CrossoverRate fields are used as they are, therefore you
+// should start from the DefaultPopulationConfig to get their defaults. The
+// Innovations field is replaced with a new registry if it is nil.
+type PopulationConfig struct {
+	// Innovations is the innovation registry of the population. You can set
+	// a restored registry for continuing a previous experiment. A new
+	// registry is created if it is nil.
+	Innovations *Innovations
+	// Species is the configuration for grouping the networks into species.
+	Species SpeciesConfig
+	// Size is the number of networks in each generation.
+	Size int
+	// Inputs is the number of input nodes of the networks.
+	Inputs int
+	// Outputs is the number of output nodes of the networks.
+	Outputs int
+	// MutationRate is passed to the networks. See the NEAT.Mutate method.
+	MutationRate int
+	// Recurrent enables the recurrent mode of the networks. See the
+	// NEAT.SetRecurrent method.
+	Recurrent bool
+	// Elitism is the number of the best networks of each species that are
+	// copied to the next generation without any changes. Zero disables the
+	// elitism. The default value is 1.
+	Elitism int
+	// SurvivalRate is the portion of the best networks of each species that
+	// are allowed to reproduce. At least the best network of each species
+	// reproduces. The default value is 0.2.
+	SurvivalRate float64
+	// CrossoverRate is the chance of an offspring being produced by the
+	// crossover of two parents rather than cloning one. Zero produces all
+	// offspring by mutation. The default value is 0.75.
+	CrossoverRate float64
+}
+
+// DefaultPopulationConfig returns a PopulationConfig with the default values.
+// You should set the Size, Inputs and Outputs fields before passing it to the
+// NewPopulation constructor.
+func DefaultPopulationConfig() *PopulationConfig {
+	return &PopulationConfig{
+		Species:       *DefaultSpeciesConfig(),
+		Elitism:       1,
+		SurvivalRate:  0.2,
+		CrossoverRate: 0.75,
+	}
+}
+
+func (c *PopulationConfig) setDefaults() {
+	if c.Innovations == nil {
+		c.Innovations = NewInnovations()
+	}
+}
+
+// GenerationStats contains the statistics of a generation.
+type GenerationStats struct {
+	Generation      int
+	Species         int
+	BestFitness     float64
+	MeanFitness     float64
+	MeanNodes       float64
+	MeanConnections float64
+}
+
+func (g GenerationStats) String() string {
+	return fmt.Sprintf("gen %d: best %.3f mean %.3f species %d nodes %.1f connections %.1f",
+		g.Generation, g.BestFitness, g.MeanFitness, g.Species, g.MeanNodes, g.MeanConnections)
+}
+
+// Population owns a set of NEAT networks and evolves them to produce new
+// generations. The fitness of each network should be set before calling the
+// Evolve method. In the simulation each network can be assigned to an entity
+// and the fitness is set with SetFitness when the entity dies, or it can be
+// used in an offline loop with the Evaluate method.
+type Population struct {
+	rand            *stdrand.Rand
+	species         *Speciation
+	champion        *NEAT
+	networks        []*NEAT
+	fitness         []float64
+	stats           []GenerationStats
+	config          PopulationConfig
+	championFitness float64
+	generation      int
+}
+
+// ErrInvalidConfig is returned when the configuration is not valid.
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// NewPopulation returns a new Population with the given configuration. All
+// networks of the first generation have the same structure with random
+// weights.
+func NewPopulation(c *PopulationConfig, rand *stdrand.Rand) (*Population, error) {
+	config := *c
+	config.setDefaults()
+	if config.Size < 1 {
+		return nil, fmt.Errorf("%w: population size %d", ErrInvalidConfig, config.Size)
+	}
+	if config.Inputs < 1 || config.Outputs < 1 {
+		return nil, fmt.Errorf("%w: %d inputs and %d outputs", ErrInvalidConfig, config.Inputs, config.Outputs)
+	}
+	if config.SurvivalRate < 0 || config.SurvivalRate > 1 {
+		return nil, fmt.Errorf("%w: survival rate %f", ErrInvalidConfig, config.SurvivalRate)
+	}
+
+	networks := make([]*NEAT, config.Size)
+	for i := range networks {
+		networks[i] = NewNEATWithInnovations(config.Inputs, config.Outputs, config.MutationRate, rand, config.Innovations)
+		networks[i].SetRecurrent(config.Recurrent)
+	}
+	return &Population{
+		rand:     rand,
+		species:  NewSpeciation(&config.Species, rand),
+		networks: networks,
+		fitness:  make([]float64, config.Size),
+		config:   config,
+	}, nil
+}
+
+// Networks returns the networks of the current generation. The index of each
+// network should be used for setting its fitness.
+func (p *Population) Networks() []*NEAT {
+	return p.networks
+}
+
+// Innovations returns the innovation registry of the population. It should be
+// saved alongside the networks for continuing the experiment later.
+func (p *Population) Innovations() *Innovations {
+	return p.config.Innovations
+}
+
+// Generation returns the number of the current generation, starting from 0.
+func (p *Population) Generation() int {
+	return p.generation
+}
+
+// Species returns the species of the last evolved generation.
+func (p *Population) Species() []*Species {
+	return p.species.Species()
+}
+
+// Stats returns the statistics of all evolved generations.
+func (p *Population) Stats() []GenerationStats {
+	return p.stats
+}
+
+// Champion returns the network with the best fitness seen across all the
+// generations. It returns nil before the first generation is evolved.
+func (p *Population) Champion() *NEAT {
+	return p.champion
+}
+
+// SetFitness sets the fitness of the network at the given index. Fitness
+// values should not be negative.
+func (p *Population) SetFitness(index int, fitness float64) {
+	p.fitness[index] = fitness
+}
+
+// Evaluate sets the fitness of all networks by calling fn for each one.
+func (p *Population) Evaluate(fn func(*NEAT) float64) {
+	for i, n := range p.networks {
+		p.fitness[i] = fn(n)
+	}
+}
+
+// Evolve produces the next generation from the current one, and returns the
+// statistics of the current generation. The networks are grouped into
+// species, and the stagnant species are culled. Each species then produces a
+// number of offspring proportional to its shared fitness. The best networks
+// of each species are copied unchanged, and the rest are produced by
+// crossover and mutation of the best networks of the species.
+func (p *Population) Evolve() (GenerationStats, error) {
+	stats := p.currentStats()
+	if err := p.species.Speciate(p.networks, p.fitness); err != nil {
+		return stats, fmt.Errorf("speciating: %w", err)
+	}
+	p.species.Cull()
+	stats.Species = len(p.species.Species())
+	p.stats = append(p.stats, stats)
+
+	species := p.species.Species()
+	offspring := p.allocateOffspring(species)
+	next := make([]*NEAT, 0, p.config.Size)
+	for i, sp := range species {
+		next = p.reproduce(next, sp, offspring[i])
+	}
+
+	p.networks = next
+	p.fitness = make([]float64, len(next))
+	p.generation++
+	p.config.Innovations.NextGeneration()
+	return stats, nil
+}
+
+// currentStats calculates the statistics of the current generation, and
+// records the champion.
+func (p *Population) currentStats() GenerationStats {
+	stats := GenerationStats{
+		Generation:  p.generation,
+		BestFitness: math.Inf(-1),
+	}
+	var best *NEAT
+	for i, n := range p.networks {
+		f := p.fitness[i]
+		stats.MeanFitness += f
+		stats.MeanNodes += float64(len(n.nodes))
+		stats.MeanConnections += float64(len(n.connections()))
+		if f > stats.BestFitness {
+			stats.BestFitness = f
+			best = n
+		}
+	}
+	size := float64(len(p.networks))
+	stats.MeanFitness /= size
+	stats.MeanNodes /= size
+	stats.MeanConnections /= size
+
+	if p.champion == nil || stats.BestFitness > p.championFitness {
+		p.champion = best.Clone()
+		p.championFitness = stats.BestFitness
+	}
+	return stats
+}
+
+// allocateOffspring returns the number of offspring each species is allowed to
+// produce, proportional to the species' total adjusted fitness. The species
+// with the best network always receives enough offspring for its elites.
+func (p *Population) allocateOffspring(species []*Species) []int {
+	shares := make([]float64, len(species))
+	var total float64
+	for i, sp := range species {
+		shares[i] = max(sp.TotalAdjustedFitness(), 0)
+		total += shares[i]
+	}
+	if total == 0 {
+		for i := range shares {
+			shares[i] = 1
+		}
+		total = float64(len(shares))
+	}
+
+	// The largest remainder method makes sure the sum is always the size of
+	// the population.
+	counts := make([]int, len(species))
+	remainders := make([]float64, len(species))
+	allocated := 0
+	for i := range shares {
+		exact := shares[i] / total * float64(p.config.Size)
+		counts[i] = int(exact)
+		remainders[i] = exact - float64(counts[i])
+		allocated += counts[i]
+	}
+	order := make([]int, len(species))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(remainders[b], remainders[a])
+	})
+	for i := 0; allocated < p.config.Size; i++ {
+		counts[order[i%len(order)]]++
+		allocated++
+	}
+
+	best := 0
+	for i, sp := range species {
+		if slices.Max(sp.fitness) > slices.Max(species[best].fitness) {
+			best = i
+		}
+	}
+	for counts[best] < min(p.config.Elitism, p.config.Size) {
+		largest := -1
+		for i := range counts {
+			if i != best && counts[i] > 0 && (largest == -1 || counts[i] > counts[largest]) {
+				largest = i
+			}
+		}
+		if largest == -1 {
+			break
+		}
+		counts[largest]--
+		counts[best]++
+	}
+	return counts
+}
+
+// reproduce appends count offspring of the species to the next slice.
+func (p *Population) reproduce(next []*NEAT, sp *Species, count int) []*NEAT {
+	if count == 0 {
+		return next
+	}
+	order := make([]int, len(sp.members))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(sp.fitness[b], sp.fitness[a])
+	})
+
+	elites := min(p.config.Elitism, count, len(order))
+	for _, i := range order[:elites] {
+		next = append(next, sp.members[i].Clone())
+	}
+
+	parents := max(int(math.Ceil(p.config.SurvivalRate*float64(len(order)))), 1)
+	order = order[:parents]
+	for i := elites; i < count; i++ {
+		a := order[p.rand.Intn(len(order))]
+		var child *NEAT
+		if len(order) > 1 && p.rand.Float64() < p.config.CrossoverRate {
+			b := order[p.rand.Intn(len(order))]
+			if sp.fitness[b] > sp.fitness[a] {
+				a, b = b, a
+			}
+			child = Crossover(sp.members[a], sp.members[b])
+		} else {
+			child = sp.members[a].Clone()
+		}
+		next = append(next, child.Mutate())
+	}
+	return next
+}
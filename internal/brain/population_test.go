@@ -0,0 +1,144 @@
+package brain
+
+import (
+	stdrand "math/rand"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestPopulation(t *testing.T) {
+	t.Parallel()
+	t.Run("NewPopulation", testPopulationNewPopulation)
+	t.Run("Config", testPopulationConfig)
+	t.Run("Evolve", testPopulationEvolve)
+	t.Run("SetFitness", testPopulationSetFitness)
+}
+
+func testPopulationNewPopulation(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	tcs := map[string]*PopulationConfig{
+		"zero size":        {Inputs: 1, Outputs: 1},
+		"zero inputs":      {Size: 10, Outputs: 1},
+		"zero outputs":     {Size: 10, Inputs: 1},
+		"bad survival":     {Size: 10, Inputs: 1, Outputs: 1, SurvivalRate: 2},
+		"negative survial": {Size: 10, Inputs: 1, Outputs: 1, SurvivalRate: -1},
+	}
+	for name, c := range tcs {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := NewPopulation(c, r)
+			assert.IsError(t, err, ErrInvalidConfig)
+		})
+	}
+
+	c := DefaultPopulationConfig()
+	c.Size, c.Inputs, c.Outputs = 10, 3, 2
+	p, err := NewPopulation(c, r)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, len(p.Networks()))
+	assert.Equal(t, 0, p.Generation())
+	assert.Zero(t, p.Champion())
+}
+
+func testPopulationConfig(t *testing.T) {
+	t.Parallel()
+	c := DefaultPopulationConfig()
+	assert.Equal(t, 1, c.Elitism)
+	assert.Equal(t, 0.2, c.SurvivalRate)
+	assert.Equal(t, 0.75, c.CrossoverRate)
+
+	// The explicit zeros are kept for disabling the elitism and the
+	// crossover.
+	c.Elitism, c.SurvivalRate, c.CrossoverRate = 0, 0, 0
+	c.setDefaults()
+	assert.Equal(t, 0, c.Elitism)
+	assert.Equal(t, 0.0, c.SurvivalRate)
+	assert.Equal(t, 0.0, c.CrossoverRate)
+	assert.NotZero(t, c.Innovations)
+
+	r := stdrand.New(stdrand.NewSource(1))
+	c.Size, c.Inputs, c.Outputs, c.MutationRate = 10, 2, 1, 20
+	p, err := NewPopulation(c, r)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, p.config.Elitism)
+	assert.Equal(t, 0.0, p.config.CrossoverRate)
+	for i := 0; i < 5; i++ {
+		p.Evaluate(fitnessFor(2))
+		_, err := p.Evolve()
+		assert.NoError(t, err)
+		assert.Equal(t, 10, len(p.Networks()))
+	}
+}
+
+// fitnessFor returns a fitness function that rewards networks producing an
+// output close to 1 for all inputs set to 1.
+func fitnessFor(inputs int) func(*NEAT) float64 {
+	input := make([]float64, inputs)
+	for i := range input {
+		input[i] = 1
+	}
+	return func(n *NEAT) float64 {
+		out, err := n.Predict(input)
+		if err != nil {
+			return 0
+		}
+		d := out[0] - 1
+		return 1 / (1 + d*d)
+	}
+}
+
+func testPopulationEvolve(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(2))
+	c := DefaultPopulationConfig()
+	c.Size, c.Inputs, c.Outputs, c.MutationRate = 50, 3, 1, 20
+	p, err := NewPopulation(c, r)
+	assert.NoError(t, err)
+
+	fitness := fitnessFor(3)
+	for i := 0; i < 30; i++ {
+		p.Evaluate(fitness)
+		stats, err := p.Evolve()
+		assert.NoError(t, err)
+		assert.Equal(t, i, stats.Generation)
+		assert.True(t, stats.Species > 0)
+		assert.True(t, stats.MeanNodes >= 4)
+		assert.True(t, stats.BestFitness >= stats.MeanFitness)
+		assert.Equal(t, 50, len(p.Networks()))
+		assert.Equal(t, i+1, p.Generation())
+	}
+
+	stats := p.Stats()
+	assert.Equal(t, 30, len(stats))
+	for i := 1; i < len(stats); i++ {
+		// The elites are kept, so the best fitness should never drop.
+		assert.True(t, stats[i].BestFitness >= stats[i-1].BestFitness,
+			"generation %d: %f < %f", i, stats[i].BestFitness, stats[i-1].BestFitness)
+	}
+	assert.True(t, stats[len(stats)-1].BestFitness > stats[0].BestFitness)
+	assert.Equal(t, stats[len(stats)-1].BestFitness, fitness(p.Champion()))
+}
+
+func testPopulationSetFitness(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(3))
+	c := DefaultPopulationConfig()
+	c.Size, c.Inputs, c.Outputs = 5, 2, 2
+	p, err := NewPopulation(c, r)
+	assert.NoError(t, err)
+	best := p.Networks()[3]
+	for i := range p.Networks() {
+		p.SetFitness(i, float64(i%2))
+	}
+	p.SetFitness(3, 10)
+
+	stats, err := p.Evolve()
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, stats.BestFitness)
+	assert.Equal(t, 2.2, stats.MeanFitness)
+	assert.Equal(t, innovations(best), innovations(p.Champion()))
+	assert.Equal(t, 5, len(p.Networks()))
+}
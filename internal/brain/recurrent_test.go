@@ -0,0 +1,169 @@
+package brain
+
+import (
+	stdrand "math/rand"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestRecurrent(t *testing.T) {
+	t.Parallel()
+	t.Run("SelfLoop", testRecurrentSelfLoop)
+	t.Run("BackEdge", testRecurrentBackEdge)
+	t.Run("FeedForward", testRecurrentFeedForward)
+	t.Run("Mutate", testRecurrentMutate)
+	t.Run("Crossover", testRecurrentCrossover)
+	t.Run("Disable", testRecurrentDisable)
+}
+
+// connect adds an enabled connection between the nodes with the given IDs.
+func connect(n *NEAT, in, out int, weight float64) {
+	var inNode, outNode *Node
+	for _, node := range n.nodes {
+		if node.ID == in {
+			inNode = node
+		}
+		if node.ID == out {
+			outNode = node
+		}
+	}
+	outNode.incomming = append(outNode.incomming, &Connection{
+		inNode:     inNode,
+		outNode:    outNode,
+		weight:     weight,
+		enabled:    true,
+		innovation: n.innovations.connection(in, out),
+	})
+}
+
+// accumulator returns a network that adds its input to its previous output.
+func accumulator(r *stdrand.Rand) *NEAT {
+	n := NewNEAT(1, 1, 0, r)
+	n.SetRecurrent(true)
+	n.connections()[0].weight = 1
+	n.nodes[1].Bias = 0
+	connect(n, 2, 2, 1)
+	return n
+}
+
+func testRecurrentSelfLoop(t *testing.T) {
+	t.Parallel()
+	n := accumulator(stdrand.New(stdrand.NewSource(1)))
+	for _, want := range []float64{1, 2, 3, 4} {
+		got, err := n.Predict([]float64{1})
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{want}, got)
+	}
+	got, err := n.Predict([]float64{-10})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{-6}, got)
+
+	n.Reset()
+	got, err = n.Predict([]float64{1})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1}, got)
+
+	clone := n.Clone()
+	assert.True(t, clone.Recurrent())
+	got, err = clone.Predict([]float64{1})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1}, got)
+}
+
+func testRecurrentBackEdge(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(2))
+	// in(1) -> hidden(3) -> out(2), and out(2) -> hidden(3) as a back edge.
+	n := NewNEAT(1, 1, 0, r)
+	n.SetRecurrent(true)
+	n.connections()[0].enabled = false
+	n.nodes[1].Bias = 0
+	n.nodes = append(n.nodes, &Node{NodeType: HiddenNode, ID: n.innovations.newNode()})
+	connect(n, 1, 3, 1)
+	connect(n, 3, 2, 2)
+	connect(n, 2, 3, 1)
+
+	for _, want := range []float64{2, 6, 14} {
+		got, err := n.Predict([]float64{1})
+		assert.NoError(t, err)
+		assert.Equal(t, []float64{want}, got)
+	}
+	assert.False(t, isAcyclic(n))
+}
+
+func testRecurrentFeedForward(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(3))
+	n := NewNEAT(3, 2, 0, r)
+	input := []float64{1, 2, 3}
+	want, err := n.Predict(input)
+	assert.NoError(t, err)
+	// A network without cycles behaves the same regardless of the mode.
+	n.SetRecurrent(true)
+	for i := 0; i < 3; i++ {
+		got, err := n.Predict(input)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+}
+
+func testRecurrentMutate(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(4))
+	n := NewNEAT(3, 2, 30, r)
+	n.SetRecurrent(true)
+	selfLoop := false
+	for i := 0; i < 1000; i++ {
+		n.Mutate()
+		_, err := n.Predict([]float64{1, 2, 3})
+		assert.NoError(t, err)
+		for _, node := range n.nodes {
+			if node.connectionFrom(node.ID) != nil {
+				selfLoop = true
+			}
+		}
+	}
+	assert.False(t, isAcyclic(n))
+	assert.True(t, selfLoop, "no self-loops were created")
+}
+
+func testRecurrentCrossover(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(5))
+	fitter := accumulator(r)
+	child := Crossover(fitter, fitter.Clone())
+	assert.True(t, child.Recurrent())
+	assert.Equal(t, innovations(fitter), innovations(child))
+	assert.False(t, isAcyclic(child))
+}
+
+func testRecurrentDisable(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(6))
+	n := NewNEAT(3, 2, 30, r)
+	for i := 0; i < 20; i++ {
+		n.addRandomNode()
+	}
+	n.SetRecurrent(true)
+	for _, node := range n.nodes {
+		if node.NodeType == HiddenNode {
+			connect(n, node.ID, node.ID, 1)
+		}
+	}
+	connect(n, 4, n.nodes[len(n.nodes)-1].ID, 1)
+	assert.False(t, isAcyclic(n))
+
+	// Disabling the recurrent mode removes the cycles, so the checks of the
+	// mutations and the crossover don't walk the cycles forever.
+	n.SetRecurrent(false)
+	assert.True(t, isAcyclic(n))
+	for i := 0; i < 1000; i++ {
+		n.Mutate()
+		assert.True(t, isAcyclic(n))
+		_, err := n.Predict([]float64{1, 2, 3})
+		assert.NoError(t, err)
+	}
+	child := Crossover(n, n.Clone())
+	assert.True(t, isAcyclic(child))
+}
@@ -0,0 +1,274 @@
+package brain
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	stdrand "math/rand"
+	"slices"
+)
+
+// SpeciesConfig is the configuration for grouping the NEAT networks into
+// species. The coefficients are used as they are, therefore you should start
+// from the DefaultSpeciesConfig to get their defaults. Any zero values of the
+// Threshold and the StagnationLimit are replaced with the defaults when passed
+// to the NewSpeciation constructor.
+type SpeciesConfig struct {
+	// ExcessCoefficient is the importance of the excess genes in the
+	// compatibility distance. Zero ignores the excess genes. The default
+	// value is 1.
+	ExcessCoefficient float64
+	// DisjointCoefficient is the importance of the disjoint genes in the
+	// compatibility distance. Zero ignores the disjoint genes. The default
+	// value is 1.
+	DisjointCoefficient float64
+	// WeightCoefficient is the importance of the average weight difference of
+	// the matching genes in the compatibility distance. Zero measures only
+	// the topology. The default value is 0.4.
+	WeightCoefficient float64
+	// Threshold is the maximum compatibility distance between a network and a
+	// species' representative for the network to belong to that species. The
+	// default value is 3.
+	Threshold float64
+	// StagnationLimit is the number of generations that a species can go
+	// without improving its best fitness before it is culled. The default
+	// value is 15.
+	StagnationLimit int
+}
+
+// DefaultSpeciesConfig returns a SpeciesConfig with the default values.
+func DefaultSpeciesConfig() *SpeciesConfig {
+	return &SpeciesConfig{
+		ExcessCoefficient:   1,
+		DisjointCoefficient: 1,
+		WeightCoefficient:   0.4,
+		Threshold:           3,
+		StagnationLimit:     15,
+	}
+}
+
+func (c *SpeciesConfig) setDefaults() {
+	if c.Threshold == 0 {
+		c.Threshold = 3
+	}
+	if c.StagnationLimit == 0 {
+		c.StagnationLimit = 15
+	}
+}
+
+// Distance returns the compatibility distance between the two networks. The
+// distance is calculated as:
+//
+//	c1*E/N + c2*D/N + c3*W
+//
+// Where E is the number of excess genes, D is the number of disjoint genes, W
+// is the average weight difference of the matching genes, and N is the number
+// of genes in the larger network.
+func (c *SpeciesConfig) Distance(a, b *NEAT) float64 {
+	genesA := a.connections()
+	genesB := b.connections()
+	var i, j, disjoint, matching int
+	var weightDiff float64
+	for i < len(genesA) && j < len(genesB) {
+		ga, gb := genesA[i], genesB[j]
+		switch {
+		case ga.innovation == gb.innovation:
+			weightDiff += math.Abs(ga.weight - gb.weight)
+			matching++
+			i++
+			j++
+		case ga.innovation < gb.innovation:
+			disjoint++
+			i++
+		default:
+			disjoint++
+			j++
+		}
+	}
+	// Whatever is left in either of the networks is beyond the range of the
+	// other one.
+	excess := len(genesA) - i + len(genesB) - j
+
+	n := float64(max(len(genesA), len(genesB), 1))
+	avgWeight := 0.0
+	if matching > 0 {
+		avgWeight = weightDiff / float64(matching)
+	}
+	return c.ExcessCoefficient*float64(excess)/n +
+		c.DisjointCoefficient*float64(disjoint)/n +
+		c.WeightCoefficient*avgWeight
+}
+
+// Species is a group of networks with similar topologies. The members of a
+// species only compete with each other, which protects new structures until
+// they have had the time to optimise their weights.
+type Species struct {
+	representative *NEAT
+	members        []*NEAT
+	fitness        []float64
+	ID             int
+	bestFitness    float64
+	stagnation     int
+}
+
+// Representative returns the network that new networks are compared against
+// when deciding whether they belong to this species.
+func (s *Species) Representative() *NEAT {
+	return s.representative
+}
+
+// Members returns the networks of the current generation that belong to this
+// species.
+func (s *Species) Members() []*NEAT {
+	return s.members
+}
+
+// Fitness returns the raw fitness of the members, in the same order as the
+// Members.
+func (s *Species) Fitness() []float64 {
+	return s.fitness
+}
+
+// AdjustedFitness returns the explicitly shared fitness of the members, in the
+// same order as the Members. Each member's fitness is divided by the size of
+// the species so a large species can't take over the population.
+func (s *Species) AdjustedFitness() []float64 {
+	ret := make([]float64, len(s.fitness))
+	size := float64(len(s.fitness))
+	for i, f := range s.fitness {
+		ret[i] = f / size
+	}
+	return ret
+}
+
+// TotalAdjustedFitness returns the sum of the adjusted fitness of all the
+// members. It is used to decide how many offspring the species is allowed to
+// produce.
+func (s *Species) TotalAdjustedFitness() float64 {
+	var total float64
+	for _, f := range s.AdjustedFitness() {
+		total += f
+	}
+	return total
+}
+
+// BestFitness returns the best raw fitness this species has ever had.
+func (s *Species) BestFitness() float64 {
+	return s.bestFitness
+}
+
+// Stagnation returns the number of generations since the best fitness of the
+// species has improved.
+func (s *Species) Stagnation() int {
+	return s.stagnation
+}
+
+// Speciation groups the networks into species. The species and their
+// representatives persist between generations.
+type Speciation struct {
+	rand    *stdrand.Rand
+	species []*Species
+	config  SpeciesConfig
+	lastID  int
+}
+
+// NewSpeciation returns a new Speciation with the given configuration. Any zero
+// values of the Threshold and the StagnationLimit are replaced with the
+// defaults.
+func NewSpeciation(c *SpeciesConfig, rand *stdrand.Rand) *Speciation {
+	config := *c
+	config.setDefaults()
+	return &Speciation{
+		config: config,
+		rand:   rand,
+	}
+}
+
+// Species returns the current species.
+func (s *Speciation) Species() []*Species {
+	return s.species
+}
+
+// Config returns the configuration of the speciation after the defaults have
+// been applied.
+func (s *Speciation) Config() SpeciesConfig {
+	return s.config
+}
+
+// errFitnessSize is returned when the fitness values don't match the networks.
+var errFitnessSize = errors.New("fitness values don't match the networks")
+
+// Speciate assigns each network to the first species that its representative
+// is compatible with. If no species is compatible, a new species is created
+// with the network as its representative. Species that don't have any members
+// are removed. It updates the stagnation of each species and picks a random
+// member as the representative for the next generation.
+func (s *Speciation) Speciate(networks []*NEAT, fitness []float64) error {
+	if len(networks) != len(fitness) {
+		return fmt.Errorf("%w: %d networks and %d fitness values", errFitnessSize, len(networks), len(fitness))
+	}
+	for _, sp := range s.species {
+		sp.members = sp.members[:0]
+		sp.fitness = sp.fitness[:0]
+	}
+
+	for i, n := range networks {
+		var species *Species
+		for _, sp := range s.species {
+			if s.config.Distance(n, sp.representative) < s.config.Threshold {
+				species = sp
+				break
+			}
+		}
+		if species == nil {
+			s.lastID++
+			species = &Species{
+				ID:             s.lastID,
+				representative: n.Clone(),
+				bestFitness:    math.Inf(-1),
+			}
+			s.species = append(s.species, species)
+		}
+		species.members = append(species.members, n)
+		species.fitness = append(species.fitness, fitness[i])
+	}
+
+	s.species = slices.DeleteFunc(s.species, func(sp *Species) bool {
+		return len(sp.members) == 0
+	})
+	for _, sp := range s.species {
+		best := slices.Max(sp.fitness)
+		if best > sp.bestFitness {
+			sp.bestFitness = best
+			sp.stagnation = 0
+		} else {
+			sp.stagnation++
+		}
+		// The representative is cloned because the members might be mutated
+		// when producing the next generation.
+		sp.representative = sp.members[s.rand.Intn(len(sp.members))].Clone()
+	}
+	return nil
+}
+
+// Cull removes the species that have not improved in StagnationLimit
+// generations and returns them. The species with the best fitness is never
+// removed, so the population can't go extinct.
+func (s *Speciation) Cull() []*Species {
+	if len(s.species) == 0 {
+		return nil
+	}
+	best := slices.MaxFunc(s.species, func(a, b *Species) int {
+		return cmp.Compare(a.bestFitness, b.bestFitness)
+	})
+	var culled []*Species
+	s.species = slices.DeleteFunc(s.species, func(sp *Species) bool {
+		if sp != best && sp.stagnation >= s.config.StagnationLimit {
+			culled = append(culled, sp)
+			return true
+		}
+		return false
+	})
+	return culled
+}
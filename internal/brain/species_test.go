@@ -0,0 +1,128 @@
+package brain
+
+import (
+	"math"
+	stdrand "math/rand"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestSpecies(t *testing.T) {
+	t.Parallel()
+	t.Run("Distance", testSpeciesDistance)
+	t.Run("Speciate", testSpeciesSpeciate)
+	t.Run("AdjustedFitness", testSpeciesAdjustedFitness)
+	t.Run("Cull", testSpeciesCull)
+}
+
+func testSpeciesDistance(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	c := &SpeciesConfig{
+		ExcessCoefficient:   1,
+		DisjointCoefficient: 2,
+		WeightCoefficient:   0.5,
+	}
+	a := NewNEAT(2, 1, 0, r)
+	assert.Equal(t, 0.0, c.Distance(a, a.Clone()))
+
+	b := a.Clone()
+	b.connections()[0].weight++
+	// One of the two matching genes has a weight difference of 1.
+	assert.True(t, math.Abs(c.Distance(a, b)-0.5*0.5) < 1e-9)
+
+	// a gets two genes that are older than b's new genes, therefore they are
+	// disjoint, and b's genes are excess.
+	a.addRandomNode()
+	b.addRandomNode()
+	want := 1*2.0/4 + 2*2.0/4 + 0.5*0.5
+	assert.True(t, math.Abs(c.Distance(a, b)-want) < 1e-9, "got %f, want %f", c.Distance(a, b), want)
+	assert.Equal(t, c.Distance(a, b), c.Distance(b, a))
+
+	// A zero weight coefficient measures only the topology.
+	s := NewSpeciation(&SpeciesConfig{ExcessCoefficient: 1, DisjointCoefficient: 2}, r)
+	topology := s.Config()
+	assert.Equal(t, 0.0, topology.WeightCoefficient)
+	assert.Equal(t, 3.0, topology.Threshold)
+	assert.Equal(t, 15, topology.StagnationLimit)
+	heavier := a.Clone()
+	heavier.connections()[0].weight += 10
+	assert.Equal(t, 0.0, topology.Distance(a, heavier))
+	assert.True(t, math.Abs(topology.Distance(a, b)-(1*2.0/4+2*2.0/4)) < 1e-9)
+	assert.Equal(t, 0.0, (&SpeciesConfig{}).Distance(a, b))
+}
+
+func testSpeciesSpeciate(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(2))
+	c := DefaultSpeciesConfig()
+	c.Threshold = 1
+	s := NewSpeciation(c, r)
+	first, second := distinctNEATs(r)
+	networks := []*NEAT{first, second, first.Clone(), second.Clone(), first.Clone()}
+	err := s.Speciate(networks, []float64{1, 2, 3, 4, 5})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(s.Species()))
+	assert.Equal(t, 3, len(s.Species()[0].Members()))
+	assert.Equal(t, []float64{1, 3, 5}, s.Species()[0].Fitness())
+	assert.Equal(t, []float64{2, 4}, s.Species()[1].Fitness())
+
+	ids := []int{s.Species()[0].ID, s.Species()[1].ID}
+	// The species should persist, and the first one should be gone.
+	err = s.Speciate([]*NEAT{second.Clone(), second.Clone()}, []float64{1, 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(s.Species()))
+	assert.Equal(t, ids[1], s.Species()[0].ID)
+	assert.Equal(t, 1, s.Species()[0].Stagnation())
+	assert.Equal(t, 4.0, s.Species()[0].BestFitness())
+
+	err = s.Speciate([]*NEAT{first}, []float64{1, 2})
+	assert.Error(t, err)
+}
+
+func testSpeciesAdjustedFitness(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(3))
+	s := NewSpeciation(DefaultSpeciesConfig(), r)
+	n := NewNEAT(3, 2, 0, r)
+	err := s.Speciate([]*NEAT{n, n.Clone(), n.Clone(), n.Clone()}, []float64{4, 8, 12, 16})
+	assert.NoError(t, err)
+	sp := s.Species()[0]
+	assert.Equal(t, []float64{1, 2, 3, 4}, sp.AdjustedFitness())
+	assert.Equal(t, 10.0, sp.TotalAdjustedFitness())
+}
+
+func testSpeciesCull(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(4))
+	c := DefaultSpeciesConfig()
+	c.Threshold, c.StagnationLimit = 1, 3
+	s := NewSpeciation(c, r)
+	first, second := distinctNEATs(r)
+	networks := []*NEAT{first, second}
+	for i := 0; i < 3; i++ {
+		err := s.Speciate(networks, []float64{1, 10})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(s.Cull()))
+	}
+	err := s.Speciate(networks, []float64{1, 10})
+	assert.NoError(t, err)
+	culled := s.Cull()
+	// Both species are stagnant, but the best one is always kept.
+	assert.Equal(t, 1, len(culled))
+	assert.Equal(t, []*NEAT{first}, culled[0].Members())
+	assert.Equal(t, 1, len(s.Species()))
+	assert.Equal(t, []*NEAT{second}, s.Species()[0].Members())
+}
+
+// distinctNEATs returns two networks that always belong to different species
+// with the default coefficients and a threshold of 1.
+func distinctNEATs(r *stdrand.Rand) (first, second *NEAT) {
+	first = NewNEAT(3, 2, 0, r)
+	second = first.Clone()
+	for _, c := range second.connections() {
+		c.weight += 5
+	}
+	return first, second
+}
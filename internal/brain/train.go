@@ -0,0 +1,338 @@
+package brain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	stdrand "math/rand"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Optimiser is the algorithm that updates the parameters of a network from
+// their gradients.
+type Optimiser uint8
+
+const (
+	// SGD is the stochastic gradient descent. It moves the parameters in the
+	// opposite direction of their gradients.
+	SGD Optimiser = iota
+	// Adam adapts the step size of each parameter from the running averages
+	// of its gradients and their squares.
+	Adam
+)
+
+func (o Optimiser) String() string {
+	switch o {
+	case SGD:
+		return "SGD"
+	case Adam:
+		return "Adam"
+	}
+	return "Unknown"
+}
+
+// Loss is the function that measures the error of the predictions.
+type Loss uint8
+
+const (
+	// MeanSquared is the mean of the squared differences of the outputs and
+	// the targets.
+	MeanSquared Loss = iota
+	// CrossEntropy is the mean binary cross-entropy of the outputs. Each
+	// output is treated as an independent probability, therefore the output
+	// layer should use the Sigmoid activation and the targets should be in
+	// the [0, 1] range.
+	CrossEntropy
+)
+
+func (l Loss) String() string {
+	switch l {
+	case MeanSquared:
+		return "MeanSquared"
+	case CrossEntropy:
+		return "CrossEntropy"
+	}
+	return "Unknown"
+}
+
+// crossEntropyEpsilon keeps the logarithms of the cross-entropy finite when
+// the outputs are saturated.
+const crossEntropyEpsilon = 1e-12
+
+// Sample is an input of the network with its expected output.
+type Sample struct {
+	Input  []float64
+	Target []float64
+}
+
+// TrainConfig is the configuration of a Trainer. Any zero values are replaced
+// with the defaults when passed to the NewTrainer constructor.
+type TrainConfig struct {
+	// LearningRate is the step size of the updates. The default value is
+	// 0.01 for SGD and 0.001 for Adam.
+	LearningRate float64
+	// BatchSize is the number of samples in each update. The default value
+	// is 32.
+	BatchSize int
+	// Beta1 is the decay rate of the average of the gradients in Adam. The
+	// default value is 0.9.
+	Beta1 float64
+	// Beta2 is the decay rate of the average of the squared gradients in
+	// Adam. The default value is 0.999.
+	Beta2 float64
+	// Epsilon prevents the division by zero in Adam. The default value is
+	// 1e-8.
+	Epsilon   float64
+	Optimiser Optimiser
+	Loss      Loss
+}
+
+func (c *TrainConfig) setDefaults() {
+	if c.LearningRate == 0 {
+		c.LearningRate = 0.01
+		if c.Optimiser == Adam {
+			c.LearningRate = 0.001
+		}
+	}
+	if c.BatchSize == 0 {
+		c.BatchSize = 32
+	}
+	if c.Beta1 == 0 {
+		c.Beta1 = 0.9
+	}
+	if c.Beta2 == 0 {
+		c.Beta2 = 0.999
+	}
+	if c.Epsilon == 0 {
+		c.Epsilon = 1e-8
+	}
+}
+
+// parameters holds the values of a matrix of the network alongside their
+// gradients and the state of the optimiser.
+type parameters struct {
+	values   []float64
+	gradient []float64
+	// mean and variance are the running averages of the gradients and their
+	// squares for Adam.
+	mean     []float64
+	variance []float64
+}
+
+// Trainer fits a Network to a dataset with backpropagation. The network is
+// updated in place. A Trainer keeps the state of the optimiser between the
+// calls to Train, therefore the training can be continued in several steps.
+// It is not safe to use it concurrently, or to use the network while it is
+// being trained.
+type Trainer struct {
+	rand    *stdrand.Rand
+	network *Network
+	// params holds the weights and then the biases of each layer.
+	params []parameters
+	config TrainConfig
+	// steps is the number of updates, which is used for the bias correction
+	// of Adam.
+	steps int
+}
+
+// ErrInvalidDataset is returned when the samples don't match the shape of the
+// network.
+var ErrInvalidDataset = errors.New("invalid dataset")
+
+// NewTrainer returns a Trainer for the network. The random source is used for
+// shuffling the samples in each epoch.
+func NewTrainer(n *Network, c *TrainConfig, rand *stdrand.Rand) (*Trainer, error) {
+	config := *c
+	config.setDefaults()
+	if config.LearningRate < 0 || config.BatchSize < 0 {
+		return nil, fmt.Errorf("%w: learning rate %f and batch size %d", ErrInvalidConfig, config.LearningRate, config.BatchSize)
+	}
+	if config.Optimiser > Adam {
+		return nil, fmt.Errorf("%w: optimiser %s", ErrInvalidConfig, config.Optimiser)
+	}
+	switch config.Loss {
+	case MeanSquared:
+	case CrossEntropy:
+		if a := n.layers[len(n.layers)-1].activation; a != Sigmoid {
+			return nil, fmt.Errorf("%w: cross-entropy with %s output", ErrInvalidConfig, a)
+		}
+	default:
+		return nil, fmt.Errorf("%w: loss %s", ErrInvalidConfig, config.Loss)
+	}
+
+	t := &Trainer{
+		rand:    rand,
+		network: n,
+		config:  config,
+		params:  make([]parameters, 0, len(n.layers)*2),
+	}
+	for i := range n.layers {
+		for _, m := range []*mat.Dense{n.layers[i].weights, n.layers[i].biases} {
+			values := m.RawMatrix().Data
+			p := parameters{
+				values:   values,
+				gradient: make([]float64, len(values)),
+			}
+			if config.Optimiser == Adam {
+				p.mean = make([]float64, len(values))
+				p.variance = make([]float64, len(values))
+			}
+			t.params = append(t.params, p)
+		}
+	}
+	return t, nil
+}
+
+// Train fits the network to the dataset for the given number of epochs, and
+// returns the mean loss of each epoch. The loss of an epoch is measured while
+// its batches are being trained.
+func (t *Trainer) Train(dataset []Sample, epochs int) ([]float64, error) {
+	if len(dataset) == 0 {
+		return nil, fmt.Errorf("%w: no samples", ErrInvalidDataset)
+	}
+	inputs := t.network.inputNeurons
+	outputs := t.network.Outputs()
+	for i, s := range dataset {
+		if len(s.Input) != inputs || len(s.Target) != outputs {
+			return nil, fmt.Errorf("%w: sample %d has %d inputs and %d targets, want %d and %d",
+				ErrInvalidDataset, i, len(s.Input), len(s.Target), inputs, outputs)
+		}
+	}
+
+	order := make([]int, len(dataset))
+	for i := range order {
+		order[i] = i
+	}
+	losses := make([]float64, 0, epochs)
+	for epoch := 0; epoch < epochs; epoch++ {
+		t.rand.Shuffle(len(order), func(i, j int) {
+			order[i], order[j] = order[j], order[i]
+		})
+		var loss float64
+		for start := 0; start < len(order); start += t.config.BatchSize {
+			end := min(start+t.config.BatchSize, len(order))
+			batch := make([]Sample, 0, end-start)
+			for _, i := range order[start:end] {
+				batch = append(batch, dataset[i])
+			}
+			loss += t.backpropagate(batch)
+			t.update()
+		}
+		losses = append(losses, loss/float64(len(dataset)))
+	}
+	return losses, nil
+}
+
+// backpropagate calculates the mean gradients of the parameters for the
+// batch, and returns the sum of the losses of the samples.
+func (t *Trainer) backpropagate(batch []Sample) float64 {
+	layers := t.network.layers
+	rows := len(batch)
+	inputs := mat.NewDense(rows, t.network.inputNeurons, nil)
+	for i, s := range batch {
+		inputs.SetRow(i, s.Input)
+	}
+
+	// sums holds the values of the neurons before the activation, and
+	// activations holds them after. The first activation is the input.
+	sums := make([]*mat.Dense, len(layers))
+	activations := make([]*mat.Dense, len(layers)+1)
+	activations[0] = inputs
+	for i := range layers {
+		l := &layers[i]
+		sum := &mat.Dense{}
+		sum.Mul(activations[i], l.weights)
+		biases := l.biases.RawRowView(0)
+		sum.Apply(func(_, j int, v float64) float64 {
+			return v + biases[j]
+		}, sum)
+		out := mat.DenseCopyOf(sum)
+		l.activate(out)
+		sums[i] = sum
+		activations[i+1] = out
+	}
+
+	// delta is the gradient of the loss with respect to the sums of the
+	// current layer.
+	output := activations[len(layers)]
+	_, cols := output.Dims()
+	delta := mat.NewDense(rows, cols, nil)
+	outputActivation := layers[len(layers)-1].activation
+	var loss float64
+	for i, s := range batch {
+		for j, target := range s.Target {
+			y := output.At(i, j)
+			switch t.config.Loss {
+			case MeanSquared:
+				diff := y - target
+				loss += diff * diff / float64(cols)
+				delta.Set(i, j, 2*diff/float64(cols)*outputActivation.derivative(sums[len(layers)-1].At(i, j), y))
+			case CrossEntropy:
+				p := math.Max(crossEntropyEpsilon, math.Min(1-crossEntropyEpsilon, y))
+				loss -= (target*math.Log(p) + (1-target)*math.Log(1-p)) / float64(cols)
+				// The derivative of the sigmoid cancels out the derivative
+				// of the cross-entropy.
+				delta.Set(i, j, (y-target)/float64(cols))
+			}
+		}
+	}
+
+	scale := 1 / float64(rows)
+	for i := len(layers) - 1; i >= 0; i-- {
+		weights := t.params[i*2]
+		grad := mat.NewDense(activations[i].RawMatrix().Cols, cols, weights.gradient)
+		grad.Mul(activations[i].T(), delta)
+		grad.Scale(scale, grad)
+
+		biases := t.params[i*2+1].gradient
+		clear(biases)
+		for r := 0; r < rows; r++ {
+			for j, v := range delta.RawRowView(r) {
+				biases[j] += v * scale
+			}
+		}
+
+		if i == 0 {
+			break
+		}
+		prev := &mat.Dense{}
+		prev.Mul(delta, layers[i].weights.T())
+		activation := layers[i-1].activation
+		prev.Apply(func(r, c int, v float64) float64 {
+			return v * activation.derivative(sums[i-1].At(r, c), activations[i].At(r, c))
+		}, prev)
+		delta = prev
+		_, cols = delta.Dims()
+	}
+	return loss
+}
+
+// update applies the gradients to the parameters of the network.
+func (t *Trainer) update() {
+	t.steps++
+	rate := t.config.LearningRate
+	switch t.config.Optimiser {
+	case SGD:
+		for _, p := range t.params {
+			for i, g := range p.gradient {
+				p.values[i] -= rate * g
+			}
+		}
+	case Adam:
+		beta1, beta2 := t.config.Beta1, t.config.Beta2
+		// The averages start from zero, therefore they are corrected towards
+		// the actual values in the first steps.
+		correction1 := 1 - math.Pow(beta1, float64(t.steps))
+		correction2 := 1 - math.Pow(beta2, float64(t.steps))
+		for _, p := range t.params {
+			for i, g := range p.gradient {
+				p.mean[i] = beta1*p.mean[i] + (1-beta1)*g
+				p.variance[i] = beta2*p.variance[i] + (1-beta2)*g*g
+				mean := p.mean[i] / correction1
+				variance := p.variance[i] / correction2
+				p.values[i] -= rate * mean / (math.Sqrt(variance) + t.config.Epsilon)
+			}
+		}
+	}
+}
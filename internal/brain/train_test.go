@@ -0,0 +1,178 @@
+package brain
+
+import (
+	"math"
+	stdrand "math/rand"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+func TestTrainer(t *testing.T) {
+	t.Parallel()
+	t.Run("Gradients", testTrainerGradients)
+	t.Run("XOR", testTrainerXOR)
+	t.Run("SGD", testTrainerSGD)
+	t.Run("Continue", testTrainerContinue)
+	t.Run("Errors", testTrainerErrors)
+}
+
+// randomNetwork returns a network with the given layer sizes, activations and
+// random weights.
+func randomNetwork(t *testing.T, r *stdrand.Rand, inputs int, sizes []int, activations []Activation) *Network {
+	t.Helper()
+	layers := make([]Layer, len(sizes))
+	prev := inputs
+	for i, size := range sizes {
+		layers[i] = Layer{
+			Weights:    make([]float64, prev*size),
+			Biases:     make([]float64, size),
+			Activation: activations[i],
+		}
+		for j := range layers[i].Weights {
+			layers[i].Weights[j] = r.NormFloat64() / math.Sqrt(float64(prev))
+		}
+		for j := range layers[i].Biases {
+			layers[i].Biases[j] = r.NormFloat64() * 0.1
+		}
+		prev = size
+	}
+	nn, err := New(&Config{InputNeurons: inputs, Layers: layers, TestCheck: true})
+	assert.NoError(t, err)
+	return nn
+}
+
+func xorDataset() []Sample {
+	return []Sample{
+		{Input: []float64{0, 0}, Target: []float64{0}},
+		{Input: []float64{0, 1}, Target: []float64{1}},
+		{Input: []float64{1, 0}, Target: []float64{1}},
+		{Input: []float64{1, 1}, Target: []float64{0}},
+	}
+}
+
+// testTrainerGradients compares the gradients of the backpropagation with
+// the numerical gradients of the loss.
+func testTrainerGradients(t *testing.T) {
+	t.Parallel()
+	for _, loss := range []Loss{MeanSquared, CrossEntropy} {
+		r := stdrand.New(stdrand.NewSource(1))
+		nn := randomNetwork(t, r, 3, []int{4, 3, 2}, []Activation{Tanh, Gaussian, Sigmoid})
+		trainer, err := NewTrainer(nn, &TrainConfig{Loss: loss}, r)
+		assert.NoError(t, err)
+		batch := []Sample{
+			{Input: []float64{0.5, -0.3, 0.8}, Target: []float64{1, 0}},
+			{Input: []float64{-0.1, 0.9, 0.2}, Target: []float64{0.2, 0.7}},
+			{Input: []float64{0.3, 0.3, -0.7}, Target: []float64{0, 1}},
+		}
+		meanLoss := func() float64 {
+			return trainer.backpropagate(batch) / float64(len(batch))
+		}
+		meanLoss()
+		want := make([][]float64, len(trainer.params))
+		for i, p := range trainer.params {
+			want[i] = append([]float64(nil), p.gradient...)
+		}
+
+		const h = 1e-6
+		for i, p := range trainer.params {
+			for j := range p.values {
+				v := p.values[j]
+				p.values[j] = v + h
+				plus := meanLoss()
+				p.values[j] = v - h
+				minus := meanLoss()
+				p.values[j] = v
+				got := (plus - minus) / (2 * h)
+				assert.True(t, math.Abs(got-want[i][j]) < 1e-6,
+					"%s: param %d/%d: numerical %f, backpropagation %f", loss, i, j, got, want[i][j])
+			}
+		}
+	}
+}
+
+func testTrainerXOR(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(2))
+	nn := randomNetwork(t, r, 2, []int{8, 1}, []Activation{Tanh, Sigmoid})
+	trainer, err := NewTrainer(nn, &TrainConfig{
+		Optimiser:    Adam,
+		Loss:         CrossEntropy,
+		LearningRate: 0.05,
+		BatchSize:    4,
+	}, r)
+	assert.NoError(t, err)
+
+	losses, err := trainer.Train(xorDataset(), 500)
+	assert.NoError(t, err)
+	assert.Equal(t, 500, len(losses))
+	assert.True(t, losses[len(losses)-1] < losses[0]/10, "loss went from %f to %f", losses[0], losses[len(losses)-1])
+	for _, s := range xorDataset() {
+		got, err := nn.Predict(s.Input)
+		assert.NoError(t, err)
+		assert.True(t, math.Abs(got[0]-s.Target[0]) < 0.1, "%v: want %v, got %v", s.Input, s.Target, got)
+	}
+}
+
+// testTrainerSGD fits a linear function with mini-batches.
+func testTrainerSGD(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(3))
+	dataset := make([]Sample, 100)
+	for i := range dataset {
+		a, b := r.Float64()*2-1, r.Float64()*2-1
+		dataset[i] = Sample{
+			Input:  []float64{a, b},
+			Target: []float64{0.5*a - 0.3*b + 0.1},
+		}
+	}
+	nn := randomNetwork(t, r, 2, []int{1}, []Activation{Identity})
+	trainer, err := NewTrainer(nn, &TrainConfig{LearningRate: 0.1, BatchSize: 10}, r)
+	assert.NoError(t, err)
+
+	losses, err := trainer.Train(dataset, 200)
+	assert.NoError(t, err)
+	assert.True(t, losses[len(losses)-1] < 1e-6, "final loss %f", losses[len(losses)-1])
+	want := []float64{0.5, -0.3, 0.1}
+	got := append(append([]float64(nil), nn.layers[0].weights.RawMatrix().Data...), nn.layers[0].biases.RawMatrix().Data...)
+	assert.True(t, almostEqual(want, got, 1e-3), "want %v, got %v", want, got)
+}
+
+// testTrainerContinue makes sure the training can be continued with the same
+// trainer.
+func testTrainerContinue(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(4))
+	nn := randomNetwork(t, r, 2, []int{4, 1}, []Activation{Tanh, Sigmoid})
+	trainer, err := NewTrainer(nn, &TrainConfig{Optimiser: Adam, LearningRate: 0.05}, r)
+	assert.NoError(t, err)
+	first, err := trainer.Train(xorDataset(), 100)
+	assert.NoError(t, err)
+	second, err := trainer.Train(xorDataset(), 100)
+	assert.NoError(t, err)
+	assert.True(t, second[len(second)-1] < first[0])
+	assert.Equal(t, 200, trainer.steps)
+}
+
+func testTrainerErrors(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(5))
+	nn := randomNetwork(t, r, 2, []int{3, 1}, []Activation{ReLU, Tanh})
+	_, err := NewTrainer(nn, &TrainConfig{Loss: CrossEntropy}, r)
+	assert.IsError(t, err, ErrInvalidConfig)
+	_, err = NewTrainer(nn, &TrainConfig{Loss: Loss(10)}, r)
+	assert.IsError(t, err, ErrInvalidConfig)
+	_, err = NewTrainer(nn, &TrainConfig{Optimiser: Optimiser(10)}, r)
+	assert.IsError(t, err, ErrInvalidConfig)
+	_, err = NewTrainer(nn, &TrainConfig{BatchSize: -1}, r)
+	assert.IsError(t, err, ErrInvalidConfig)
+
+	trainer, err := NewTrainer(nn, &TrainConfig{}, r)
+	assert.NoError(t, err)
+	_, err = trainer.Train(nil, 1)
+	assert.IsError(t, err, ErrInvalidDataset)
+	_, err = trainer.Train([]Sample{{Input: []float64{1}, Target: []float64{1}}}, 1)
+	assert.IsError(t, err, ErrInvalidDataset)
+	_, err = trainer.Train([]Sample{{Input: []float64{1, 2}, Target: []float64{1, 2}}}, 1)
+	assert.IsError(t, err, ErrInvalidDataset)
+}
@@ -3,21 +3,55 @@ package component
 
 import (
 	"github.com/arsham/neuragene/internal/asset"
+	"github.com/arsham/neuragene/internal/genome"
 	"github.com/arsham/neuragene/internal/geom"
+	"github.com/arsham/neuragene/internal/sparse"
 )
 
-// Manager manages components for entities. All values are maps of entity IDs
-// to their respective components. When an entity is removed, its ID is removed
-// from all the maps.
+// Manager manages components for entities. Each component type is stored in a
+// sparse set, which keeps the components in a dense slice in the order of the
+// entity IDs. When an entity is removed, its components should be removed
+// with the Remove method.
 type Manager struct {
 	// Position holds the position, scale, and velocity of entities.
-	Position map[uint64]*Position
+	Position *sparse.Set[Position]
 	// Sprite contains the sprite names for renderable entities.
-	Sprite map[uint64]*Sprite
+	Sprite *sparse.Set[Sprite]
 	// Lifespan contains the lifespan of entities.
-	Lifespan map[uint64]*Lifespan
+	Lifespan *sparse.Set[Lifespan]
 	// BoundingBox contains the bounding box of entities.
-	BoundingBox map[uint64]*BoundingBox
+	BoundingBox *sparse.Set[BoundingBox]
+	// DNA contains the DNA of organisms.
+	DNA *sparse.Set[*genome.DNA]
+	// Phenotype contains the expressed traits of organisms.
+	Phenotype *sparse.Set[Phenotype]
+}
+
+// NewManager returns a new Manager with pre-allocated memory for the given
+// number of entities.
+func NewManager(size int) *Manager {
+	return &Manager{
+		Position:    sparse.NewSet[Position](size),
+		Sprite:      sparse.NewSet[Sprite](size),
+		Lifespan:    sparse.NewSet[Lifespan](size),
+		BoundingBox: sparse.NewSet[BoundingBox](size),
+		DNA:         sparse.NewSet[*genome.DNA](size),
+		Phenotype:   sparse.NewSet[Phenotype](size),
+	}
+}
+
+// Remove removes all the components of the entity. The DNA of the entity is
+// resolved.
+func (m *Manager) Remove(id uint64) {
+	m.Position.Remove(id)
+	m.Sprite.Remove(id)
+	m.Lifespan.Remove(id)
+	m.BoundingBox.Remove(id)
+	if dna, ok := m.DNA.Get(id); ok {
+		(*dna).Resolve()
+		m.DNA.Remove(id)
+	}
+	m.Phenotype.Remove(id)
 }
 
 // Position component holds the position, scale, velocity vector movement of an
@@ -79,10 +113,13 @@ type Sprite struct {
 }
 
 // Lifespan specifies the total amount of frames that the entity should stay
-// alive, and the remaining frames.
+// alive, and the remaining frames. The entities with a phenotype also spend
+// their nutrition on each frame, and they starve when it runs out.
 type Lifespan struct {
 	Total     int
 	Remaining int
+	// Nutrition is the amount of nutrition the entity has left.
+	Nutrition float64
 }
 
 // BoundingBox specifies the bounding box in which an entity will collide with
@@ -91,6 +128,24 @@ type BoundingBox struct {
 	geom.Rect
 }
 
+// Phenotype holds the characteristics of an organism that are expressed from
+// its DNA.
+type Phenotype struct {
+	// Scale is the scale of the organism when it is born.
+	Scale float64
+	// MaxScale is the largest scale the organism can grow to.
+	MaxScale float64
+	// Growth is the amount the scale grows on each frame.
+	Growth float64
+	// MaxVelocity is the maximum length of the velocity vector.
+	MaxVelocity float64
+	// Metabolism is the amount of nutrition the organism consumes on each
+	// frame.
+	Metabolism float64
+	// Lifespan is the total amount of frames the organism lives.
+	Lifespan int
+}
+
 // State is used to identify a system's functionality. At each state, the
 // system has a certain behaviour that can be determined by the bit masks based
 // on the available constants.
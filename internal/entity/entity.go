@@ -2,6 +2,7 @@
 package entity
 
 import (
+	"cmp"
 	"slices"
 	"sync/atomic"
 
@@ -30,13 +31,17 @@ const (
 	// Rigid marks the entity that should cause other entities with Collides
 	// mask to be bounced, but it is not be resolved in terms of collisions.
 	Rigid
+	// HasDNA marks an organism that has a DNA, and its phenotype is
+	// expressed from its DNA.
+	HasDNA
 )
 
 // An Entity is an element in the game that can have at least one component.
-// Each component is managed by the component.Manager as a map if entity ID to
-// its respective component. When an Entity is removed, its ID is removed from
-// all the maps in the Manager. You should not create an Entity directly,
-// instead you should use the Manager's NewEntity method.
+// Each component is managed by the component.Manager in a set that maps the
+// entity IDs to their respective components. When an Entity is removed, its
+// components are removed from all the sets in the Manager. You should not
+// create an Entity directly, instead you should use the Manager's NewEntity
+// method.
 type Entity struct {
 	ID   uint64
 	mask Mask
@@ -79,7 +84,7 @@ func (m *Manager) NewEntity(mask Mask) *Entity {
 	}
 	m.toAdd = append(m.toAdd, e)
 	if mask&Positioned == Positioned {
-		m.components.Position[e.ID] = &component.Position{}
+		m.components.Position.Set(e.ID, component.Position{})
 	}
 	return e
 }
@@ -95,7 +100,7 @@ func (m *Manager) MapByMask(mask Mask, fn func(*Entity)) {
 }
 
 // Update moves new entities from the toAdd slice to entities slice, and
-// removes any that are dead.
+// removes any that are dead along with their components.
 func (m *Manager) Update(state component.State) {
 	m.entities = append(m.entities, m.toAdd...)
 	clear(m.toAdd)
@@ -103,7 +108,11 @@ func (m *Manager) Update(state component.State) {
 
 	if state&component.StateLimitLifespans == component.StateLimitLifespans {
 		m.entities = slices.DeleteFunc(m.entities, func(e *Entity) bool {
-			return e.mask&Died == Died
+			if e.mask&Died != Died {
+				return false
+			}
+			m.components.Remove(e.ID)
+			return true
 		})
 	}
 }
@@ -117,3 +126,20 @@ func (m *Manager) Len() int {
 func (m *Manager) Kill(e *Entity) {
 	e.mask |= Died
 }
+
+// KillByID marks the entity with the given ID as dead. It will be removed on
+// the next Update call. It returns false if the entity doesn't exist. The
+// entities are kept in the order of their IDs, therefore the entity is found
+// with a binary search.
+func (m *Manager) KillByID(id uint64) bool {
+	for _, list := range []List{m.entities, m.toAdd} {
+		i, ok := slices.BinarySearchFunc(list, id, func(e *Entity, id uint64) int {
+			return cmp.Compare(e.ID, id)
+		})
+		if ok {
+			m.Kill(list[i])
+			return true
+		}
+	}
+	return false
+}
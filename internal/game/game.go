@@ -14,6 +14,7 @@ import (
 	"github.com/arsham/neuragene/internal/component"
 	"github.com/arsham/neuragene/internal/config"
 	"github.com/arsham/neuragene/internal/entity"
+	"github.com/arsham/neuragene/internal/lineage"
 	"github.com/arsham/neuragene/internal/scene"
 	"github.com/arsham/neuragene/internal/system"
 )
@@ -65,13 +66,9 @@ func NewEngine(env *config.Env, filesystem fs.FS) (*Engine, error) {
 	}
 
 	size := 1000
-	components := &component.Manager{
-		Position:    make(map[uint64]*component.Position, size),
-		Sprite:      make(map[uint64]*component.Sprite, size),
-		Lifespan:    make(map[uint64]*component.Lifespan, size),
-		BoundingBox: make(map[uint64]*component.BoundingBox, size),
-	}
+	components := component.NewManager(size)
 	em := entity.NewManager(components, size)
+	lineages := lineage.NewStore()
 	sm := system.NewManager(10)
 	sm.Add(
 		&system.Grid{
@@ -92,9 +89,13 @@ func NewEngine(env *config.Env, filesystem fs.FS) (*Engine, error) {
 		&system.Ant{
 			Seed:         1,
 			MutationRate: 100,
+			Lineage:      lineages,
 		},
+		&system.Phenotype{},
 		&system.Position{},
-		&system.Lifespan{},
+		&system.Lifespan{
+			Lineage: lineages,
+		},
 		&system.Stats{},
 		&system.BoundingBox{
 			Size: 1,
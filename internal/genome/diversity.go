@@ -0,0 +1,167 @@
+package genome
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+)
+
+// Diversity contains the population genetics statistics of a set of DNAs. The
+// statistics are calculated for each locus. The DNAs that are shorter than a
+// locus don't take part in its statistics.
+type Diversity struct {
+	// Frequencies are the frequencies of the alleles of each locus, in the
+	// [0, 1] range.
+	Frequencies []map[rune]float64
+	// Heterozygosity is the expected heterozygosity of each locus, which is
+	// the chance of two random DNAs having different alleles. It is zero when
+	// all DNAs have the same allele.
+	Heterozygosity []float64
+	// Entropy is the Shannon entropy of each locus in bits.
+	Entropy []float64
+	// Size is the number of the DNAs.
+	Size int
+}
+
+// Analyse returns the diversity of the DNAs. It returns an empty Diversity if
+// there are no DNAs.
+func Analyse(dnas []*DNA) *Diversity {
+	loci := 0
+	for _, d := range dnas {
+		loci = max(loci, len(d.traits))
+	}
+	ret := &Diversity{
+		Frequencies:    make([]map[rune]float64, loci),
+		Heterozygosity: make([]float64, loci),
+		Entropy:        make([]float64, loci),
+		Size:           len(dnas),
+	}
+	for i := 0; i < loci; i++ {
+		counts := make(map[rune]float64)
+		total := 0.0
+		for _, d := range dnas {
+			if i < len(d.traits) {
+				counts[d.traits[i]]++
+				total++
+			}
+		}
+		homozygosity := 0.0
+		for allele, c := range counts {
+			p := c / total
+			counts[allele] = p
+			homozygosity += p * p
+			ret.Entropy[i] -= p * math.Log2(p)
+		}
+		ret.Frequencies[i] = counts
+		ret.Heterozygosity[i] = 1 - homozygosity
+	}
+	return ret
+}
+
+// MeanHeterozygosity returns the average of the heterozygosity of all loci.
+func (d *Diversity) MeanHeterozygosity() float64 {
+	return mean(d.Heterozygosity)
+}
+
+// MeanEntropy returns the average of the entropy of all loci.
+func (d *Diversity) MeanEntropy() float64 {
+	return mean(d.Entropy)
+}
+
+// Fixed returns the indices of the loci that have an allele with a frequency
+// of at least the threshold. With the threshold of 1 it returns the loci that
+// have lost all their variation.
+func (d *Diversity) Fixed(threshold float64) []int {
+	ret := make([]int, 0)
+	for i, freq := range d.Frequencies {
+		for _, p := range freq {
+			// Adding up the frequencies can be slightly off.
+			if p >= threshold-1e-9 {
+				ret = append(ret, i)
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// DistanceBins is the number of the bins of the histogram of Distances. Each
+// bin covers 100/DistanceBins percent of difference.
+const DistanceBins = 10
+
+// Distances is the distribution of the differences between pairs of DNAs, as
+// calculated with the CalculateDifference method.
+type Distances struct {
+	// Histogram is the number of the pairs in each bin. The last bin also
+	// contains the pairs with 100% difference.
+	Histogram [DistanceBins]int
+	Min       float64
+	Max       float64
+	Mean      float64
+	StdDev    float64
+	// Pairs is the number of the pairs that are compared.
+	Pairs int
+}
+
+// PairwiseDistances returns the distribution of the differences between all
+// pairs of the DNAs. It compares n*(n-1)/2 pairs, use SampleDistances for
+// large populations.
+func PairwiseDistances(dnas []*DNA) Distances {
+	values := make([]float64, 0, len(dnas)*(len(dnas)-1)/2)
+	for i := range dnas {
+		for j := i + 1; j < len(dnas); j++ {
+			values = append(values, dnas[i].CalculateDifference(dnas[j]))
+		}
+	}
+	return distribution(values)
+}
+
+// SampleDistances returns the distribution of the differences between n
+// random pairs of different DNAs.
+func SampleDistances(dnas []*DNA, n int, r *rand.Rand) Distances {
+	if len(dnas) < 2 {
+		return Distances{}
+	}
+	values := make([]float64, n)
+	for k := range values {
+		i := r.Intn(len(dnas))
+		j := r.Intn(len(dnas) - 1)
+		if j >= i {
+			j++
+		}
+		values[k] = dnas[i].CalculateDifference(dnas[j])
+	}
+	return distribution(values)
+}
+
+// distribution returns the distribution of the differences.
+func distribution(values []float64) Distances {
+	if len(values) == 0 {
+		return Distances{}
+	}
+	ret := Distances{
+		Min:   slices.Min(values),
+		Max:   slices.Max(values),
+		Mean:  mean(values),
+		Pairs: len(values),
+	}
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - ret.Mean) * (v - ret.Mean)
+		bin := min(int(v/100*DistanceBins), DistanceBins-1)
+		ret.Histogram[bin]++
+	}
+	ret.StdDev = math.Sqrt(variance / float64(len(values)))
+	return ret
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total / float64(len(values))
+}
@@ -0,0 +1,109 @@
+package genome_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/arsham/neuragene/internal/genome"
+)
+
+func dnas(t *testing.T, values ...string) []*genome.DNA {
+	t.Helper()
+	ret := make([]*genome.DNA, len(values))
+	for i, v := range values {
+		ret[i] = genome.NewDNAFromString(v)
+	}
+	t.Cleanup(func() {
+		for _, d := range ret {
+			d.Resolve()
+		}
+	})
+	return ret
+}
+
+func assertClose(t *testing.T, want, got float64) {
+	t.Helper()
+	assert.True(t, math.Abs(want-got) < 1e-6, "want %f, got %f", want, got)
+}
+
+func TestAnalyse(t *testing.T) {
+	t.Parallel()
+	t.Run("Loci", testAnalyseLoci)
+	t.Run("Lengths", testAnalyseLengths)
+	t.Run("Empty", testAnalyseEmpty)
+}
+
+func testAnalyseLoci(t *testing.T) {
+	t.Parallel()
+	d := genome.Analyse(dnas(t, "aab", "abb", "acb", "ada"))
+	assert.Equal(t, 4, d.Size)
+	assert.Equal(t, map[rune]float64{'a': 1}, d.Frequencies[0])
+	assert.Equal(t, map[rune]float64{'a': 0.25, 'b': 0.25, 'c': 0.25, 'd': 0.25}, d.Frequencies[1])
+	assert.Equal(t, map[rune]float64{'a': 0.25, 'b': 0.75}, d.Frequencies[2])
+
+	assertClose(t, 0, d.Heterozygosity[0])
+	assertClose(t, 0.75, d.Heterozygosity[1])
+	assertClose(t, 0.375, d.Heterozygosity[2])
+	assertClose(t, 0.375, d.MeanHeterozygosity())
+
+	assertClose(t, 0, d.Entropy[0])
+	assertClose(t, 2, d.Entropy[1])
+	assertClose(t, 0.811278, d.Entropy[2])
+	assertClose(t, 0.937093, d.MeanEntropy())
+
+	assert.Equal(t, []int{0}, d.Fixed(1))
+	assert.Equal(t, []int{0, 2}, d.Fixed(0.75))
+}
+
+func testAnalyseLengths(t *testing.T) {
+	t.Parallel()
+	d := genome.Analyse(dnas(t, "ab", "a", "ac"))
+	assert.Equal(t, 2, len(d.Frequencies))
+	assert.Equal(t, map[rune]float64{'b': 0.5, 'c': 0.5}, d.Frequencies[1])
+	assertClose(t, 1, d.Entropy[1])
+}
+
+func testAnalyseEmpty(t *testing.T) {
+	t.Parallel()
+	d := genome.Analyse(nil)
+	assert.Equal(t, 0, d.Size)
+	assert.Equal(t, 0.0, d.MeanHeterozygosity())
+	assert.Equal(t, 0.0, d.MeanEntropy())
+	assert.Equal(t, []int{}, d.Fixed(1))
+}
+
+func TestDistances(t *testing.T) {
+	t.Parallel()
+	t.Run("Pairwise", testDistancesPairwise)
+	t.Run("Sample", testDistancesSample)
+}
+
+func testDistancesPairwise(t *testing.T) {
+	t.Parallel()
+	d := genome.PairwiseDistances(dnas(t, "aa", "aa", "a"))
+	assert.Equal(t, 3, d.Pairs)
+	assert.Equal(t, 0.0, d.Min)
+	assert.Equal(t, 100.0, d.Max)
+	assertClose(t, 200.0/3, d.Mean)
+	assertClose(t, math.Sqrt(20000.0/9), d.StdDev)
+	assert.Equal(t, [genome.DistanceBins]int{1, 0, 0, 0, 0, 0, 0, 0, 0, 2}, d.Histogram)
+
+	assert.Equal(t, genome.Distances{}, genome.PairwiseDistances(dnas(t, "a")))
+}
+
+func testDistancesSample(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	population := dnas(t, "aa", "aa", "a")
+	d := genome.SampleDistances(population, 300, r)
+	assert.Equal(t, 300, d.Pairs)
+	assert.Equal(t, 300, d.Histogram[0]+d.Histogram[genome.DistanceBins-1])
+	assert.True(t, d.Histogram[0] > 50 && d.Histogram[0] < 150,
+		"a third of the pairs are identical: %v", d.Histogram)
+
+	d = genome.SampleDistances(dnas(t, "a", "bb"), 10, r)
+	assert.Equal(t, 0, d.Histogram[0], "a DNA is never compared with itself")
+	assert.Equal(t, genome.Distances{}, genome.SampleDistances(population[:1], 10, r))
+}
@@ -98,46 +98,10 @@ func (d *DNA) IsCompatibleWith(other *DNA) bool {
 	return d.CalculateDifference(other) < 0.04
 }
 
-// CreateOffspring creates an offspring from the two given parents. It uses the
-// DNA of the parents to create a new DNA object. It will randomly one of the
-// traits that have the least occurrence in the parents. The mutation rate is 1
-// per length of the traits, and randomly applied 3 out of 100 times.
-func CreateOffspring(p1, p2 *DNA) *DNA {
-	c := NewDNAFromString(p1.String())
-	patterns := make(map[rune]int, len(p1.traits))
-	locations := make(map[rune][]int, len(p1.traits))
-	for i := 0; i < len(p1.traits); i++ {
-		t1 := p1.traits[i]
-		t2 := p2.traits[i]
-		if t1 > t2 {
-			t2 = t1
-		}
-		c.traits[i] = t2
-		patterns[t2]++
-		locations[t2] = append(locations[t2], i)
-	}
-
-	if rand.Intn(100) < 3 {
-		least := len(p1.traits)
-		change := c.traits[0]
-		for k, v := range patterns {
-			if v < least {
-				least = v
-				change = k
-			}
-		}
-		location := locations[change]
-		var offset int
-		switch {
-		case change < 'b':
-			offset = 1
-		case change > 'y':
-			offset = -1
-		default:
-			offset = rand.Intn(2)*2 - 1
-		}
-		change += rune(offset)
-		c.traits[location[0]] = change
-	}
-	return c
+// CreateOffspring creates an offspring from the two given parents with the
+// DefaultReproduction. Use a Reproduction for choosing the crossover and the
+// mutations. You should always resolve the DNA object with calling the
+// Resolve() method.
+func CreateOffspring(p1, p2 *DNA, r *rand.Rand) *DNA {
+	return DefaultReproduction.Offspring(p1, p2, r)
 }
@@ -3,6 +3,7 @@ package genome_test
 import (
 	"fmt"
 	"math"
+	"math/rand"
 	"testing"
 
 	"github.com/alecthomas/assert/v2"
@@ -182,6 +183,7 @@ func testDNAIsCompatibleWith(t *testing.T) {
 
 func testDNACreateOffspring(t *testing.T) {
 	t.Parallel()
+	r := rand.New(rand.NewSource(1))
 	// giving the mutation a high chance to happen.
 	for i := 0; i < 10000; i++ {
 		tcs := []struct {
@@ -210,11 +212,36 @@ func testDNACreateOffspring(t *testing.T) {
 			t.Run(name, func(t *testing.T) {
 				dna1 := genome.NewDNAFromString(tc.dna1)
 				dna2 := genome.NewDNAFromString(tc.dna2)
-				child := genome.CreateOffspring(dna1, dna2)
+				child := genome.CreateOffspring(dna1, dna2, r)
 				want := genome.NewDNAFromString(tc.want)
-				diff := child.CalculateDifference(want)
-				assert.True(t, diff <= 0.1, "\nwant %s\ngot  %s (%f)", want, child, diff)
+				// At most one locus is nudged to a neighbouring value.
+				var changed int32
+				for _, trait := range genome.MustSchema(traitsOf(len(tc.want))...).Traits() {
+					d := trait.Value(child) - trait.Value(want)
+					changed += max(d, -d)
+				}
+				assert.True(t, changed <= 1, "\nwant %s\ngot  %s", want, child)
 			})
 		}
 	}
 }
+
+func TestTraits(t *testing.T) {
+	t.Parallel()
+	dna := genome.NewDNAFromString("1aZ9bY")
+	defer dna.Resolve()
+	assert.Equal(t, int32(0), genome.NutritionConsumptionTrait.Value(dna))
+	assert.Equal(t, int32(9), genome.GrowthTrait.Value(dna))
+	assert.Equal(t, int32(genome.MaxTraitValue), genome.MaxGrowthTrait.Value(dna))
+	assert.Equal(t, int32(8), genome.ScaleTrait.Value(dna))
+	assert.Equal(t, int32(10), genome.SpeedTrait.Value(dna))
+	assert.Equal(t, int32(59), genome.LifespanTrait.Value(dna))
+
+	r := rand.New(rand.NewSource(1))
+	random := genome.NewRandomDNA(r)
+	defer random.Resolve()
+	assert.Equal(t, genome.Traits.Len(), len(random.String()))
+	for i := 0; i < genome.Traits.Len(); i++ {
+		assert.True(t, random.TraitStrength(i) > 0)
+	}
+}
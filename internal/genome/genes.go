@@ -0,0 +1,416 @@
+package genome
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"slices"
+)
+
+// GeneKind is the type of the value of a gene.
+type GeneKind uint8
+
+const (
+	// FloatGene is a continuous value in the [Min, Max] range.
+	FloatGene GeneKind = iota
+	// IntGene is an integer in the [Min, Max] range.
+	IntGene
+	// BoolGene is either true or false.
+	BoolGene
+	// EnumGene is one of the options of the gene.
+	EnumGene
+)
+
+func (k GeneKind) String() string {
+	switch k {
+	case FloatGene:
+		return "Float"
+	case IntGene:
+		return "Int"
+	case BoolGene:
+		return "Bool"
+	case EnumGene:
+		return "Enum"
+	}
+	return "Unknown"
+}
+
+// defaultFloatBits is the precision of the float genes that don't set it.
+const defaultFloatBits = 16
+
+// Gene declares a typed gene of a BitDNA.
+type Gene struct {
+	// Name is the unique name of the gene.
+	Name string
+	// Options are the values of an enum gene.
+	Options []string
+	// Min and Max are the range of the float and int genes.
+	Min float64
+	Max float64
+	// Bits is the precision of a float gene, between 1 and 32. The default
+	// value is 16, which gives 65536 steps in the range.
+	Bits int
+	Kind GeneKind
+}
+
+// width returns the number of bits the gene takes.
+func (g *Gene) width() int {
+	switch g.Kind {
+	case FloatGene:
+		if g.Bits == 0 {
+			return defaultFloatBits
+		}
+		return g.Bits
+	case IntGene:
+		return bits.Len64(uint64(g.Max - g.Min))
+	case BoolGene:
+		return 1
+	default:
+		return bits.Len64(uint64(len(g.Options) - 1))
+	}
+}
+
+// levels returns the largest stored value of the gene.
+func (g *Gene) levels() uint64 {
+	switch g.Kind {
+	case FloatGene:
+		return 1<<g.width() - 1
+	case IntGene:
+		return uint64(g.Max - g.Min)
+	case BoolGene:
+		return 1
+	default:
+		return uint64(len(g.Options) - 1)
+	}
+}
+
+// validate returns an error if the gene declaration is not valid.
+func (g *Gene) validate() error {
+	switch {
+	case g.Name == "":
+		return errors.New("empty name")
+	case g.Kind > EnumGene:
+		return fmt.Errorf("unknown kind %d", g.Kind)
+	case (g.Kind == FloatGene || g.Kind == IntGene) && !(g.Min <= g.Max):
+		return fmt.Errorf("min %f is larger than max %f", g.Min, g.Max)
+	case g.Kind == FloatGene && (g.Bits < 0 || g.Bits > 32):
+		return fmt.Errorf("%d bits is out of range", g.Bits)
+	case g.Kind == IntGene && (g.Min != math.Trunc(g.Min) || g.Max != math.Trunc(g.Max)):
+		return fmt.Errorf("range [%f, %f] is not integer", g.Min, g.Max)
+	case g.Kind == IntGene && g.Max-g.Min > math.MaxUint32:
+		return fmt.Errorf("range [%f, %f] is too large", g.Min, g.Max)
+	case g.Kind == EnumGene && len(g.Options) == 0:
+		return errors.New("no options")
+	}
+	return nil
+}
+
+// Layout is the ordered set of the genes of a BitDNA. Each gene takes as few
+// bits as its values need, and the genes are packed next to each other.
+type Layout struct {
+	byName  map[string]int
+	genes   []Gene
+	offsets []int
+	bits    int
+}
+
+// NewLayout returns a layout with the given genes. It returns an
+// ErrInvalidSchema error if any of the genes is not valid, or if the names
+// are not unique.
+func NewLayout(genes ...Gene) (*Layout, error) {
+	l := &Layout{
+		byName:  make(map[string]int, len(genes)),
+		genes:   slices.Clone(genes),
+		offsets: make([]int, len(genes)),
+	}
+	for i := range l.genes {
+		g := &l.genes[i]
+		if err := g.validate(); err != nil {
+			return nil, fmt.Errorf("%w: gene %q: %w", ErrInvalidSchema, g.Name, err)
+		}
+		if _, ok := l.byName[g.Name]; ok {
+			return nil, fmt.Errorf("%w: duplicate gene %q", ErrInvalidSchema, g.Name)
+		}
+		l.byName[g.Name] = i
+		l.offsets[i] = l.bits
+		l.bits += g.width()
+	}
+	return l, nil
+}
+
+// LayoutFromSchema returns a layout with a float gene for each trait of the
+// schema, with the same names and ranges. The genes are in the order of the
+// trait indices.
+func LayoutFromSchema(s *Schema, precision int) (*Layout, error) {
+	genes := make([]Gene, s.Len())
+	for i, t := range s.traits {
+		genes[i] = Gene{
+			Name: t.Name,
+			Kind: FloatGene,
+			Min:  t.Min,
+			Max:  t.Max,
+			Bits: precision,
+		}
+	}
+	return NewLayout(genes...)
+}
+
+// Genes returns the genes of the layout in order.
+func (l *Layout) Genes() []Gene {
+	return l.genes
+}
+
+// Bits returns the number of bits of a BitDNA with this layout.
+func (l *Layout) Bits() int {
+	return l.bits
+}
+
+// Index returns the index of the gene with the given name. It returns -1 if
+// there is no such gene.
+func (l *Layout) Index(name string) int {
+	if i, ok := l.byName[name]; ok {
+		return i
+	}
+	return -1
+}
+
+// New returns a BitDNA with all genes at their minimum values.
+func (l *Layout) New() *BitDNA {
+	return &BitDNA{
+		layout: l,
+		words:  make([]uint64, (l.bits+63)/64),
+	}
+}
+
+// Decode returns the BitDNA from the compact encoding produced by the
+// MarshalBinary method.
+func (l *Layout) Decode(data []byte) (*BitDNA, error) {
+	if len(data) != (l.bits+7)/8 {
+		return nil, fmt.Errorf("%w: %d bytes, want %d", ErrInvalidDNA, len(data), (l.bits+7)/8)
+	}
+	b := l.New()
+	padded := make([]byte, len(b.words)*8)
+	copy(padded, data)
+	for i := range b.words {
+		b.words[i] = binary.LittleEndian.Uint64(padded[i*8:])
+	}
+	if extra := len(b.words)*64 - l.bits; extra > 0 && b.words[len(b.words)-1]>>(64-extra) != 0 {
+		return nil, fmt.Errorf("%w: padding bits are set", ErrInvalidDNA)
+	}
+	for i := range l.genes {
+		if b.raw(i) > l.genes[i].levels() {
+			return nil, fmt.Errorf("%w: gene %q is out of range", ErrInvalidDNA, l.genes[i].Name)
+		}
+	}
+	return b, nil
+}
+
+// FromDNA imports the string form of a DNA. The trait at each index is read
+// as a fraction of MaxTraitValue, and it is stored in the gene with the same
+// index. The DNA should have a valid value for each gene.
+func (l *Layout) FromDNA(dna *DNA) (*BitDNA, error) {
+	if len(dna.traits) != len(l.genes) {
+		return nil, fmt.Errorf("%w: %d traits, want %d", ErrInvalidDNA, len(dna.traits), len(l.genes))
+	}
+	b := l.New()
+	for i, r := range dna.traits {
+		v, ok := charValues[r]
+		if !ok {
+			return nil, fmt.Errorf("%w: gene %q has invalid value %q", ErrInvalidDNA, l.genes[i].Name, r)
+		}
+		b.setFraction(i, float64(v)/float64(MaxTraitValue))
+	}
+	return b, nil
+}
+
+// BitDNA is a DNA with typed genes that are packed into bits. Unlike the
+// DNA, whose traits are limited to the 61 values of the alphabet, each gene
+// has as many values as it needs, and the distance between two BitDNAs is
+// measured by the values of their genes.
+type BitDNA struct {
+	layout *Layout
+	words  []uint64
+}
+
+// Layout returns the layout of the BitDNA.
+func (b *BitDNA) Layout() *Layout {
+	return b.layout
+}
+
+// Clone returns a copy of the BitDNA.
+func (b *BitDNA) Clone() *BitDNA {
+	return &BitDNA{
+		layout: b.layout,
+		words:  slices.Clone(b.words),
+	}
+}
+
+// raw returns the stored bits of the gene at index i.
+func (b *BitDNA) raw(i int) uint64 {
+	offset, width := b.layout.offsets[i], b.layout.genes[i].width()
+	var v uint64
+	for j := 0; j < width; j++ {
+		bit := offset + j
+		v |= (b.words[bit/64] >> (bit % 64) & 1) << j
+	}
+	return v
+}
+
+// setRaw stores the bits of the gene at index i.
+func (b *BitDNA) setRaw(i int, v uint64) {
+	offset, width := b.layout.offsets[i], b.layout.genes[i].width()
+	for j := 0; j < width; j++ {
+		bit := offset + j
+		mask := uint64(1) << (bit % 64)
+		if v>>j&1 == 1 {
+			b.words[bit/64] |= mask
+		} else {
+			b.words[bit/64] &^= mask
+		}
+	}
+}
+
+// fraction returns the value of the gene at index i in the [0, 1] range.
+func (b *BitDNA) fraction(i int) float64 {
+	levels := b.layout.genes[i].levels()
+	if levels == 0 {
+		return 0
+	}
+	return float64(b.raw(i)) / float64(levels)
+}
+
+// setFraction sets the gene at index i to the nearest value to the fraction
+// of its range.
+func (b *BitDNA) setFraction(i int, f float64) {
+	f = max(0, min(1, f))
+	b.setRaw(i, uint64(math.Round(f*float64(b.layout.genes[i].levels()))))
+}
+
+// gene returns the index of the gene with the name and the kind. It panics if
+// there is no such gene, as it is a programming error.
+func (b *BitDNA) gene(name string, kind GeneKind) int {
+	i := b.layout.Index(name)
+	if i < 0 || b.layout.genes[i].Kind != kind {
+		panic(fmt.Sprintf("genome: no %s gene %q", kind, name))
+	}
+	return i
+}
+
+// Float returns the value of the float gene with the given name.
+func (b *BitDNA) Float(name string) float64 {
+	i := b.gene(name, FloatGene)
+	g := &b.layout.genes[i]
+	return g.Min + (g.Max-g.Min)*b.fraction(i)
+}
+
+// SetFloat sets the float gene with the given name to the nearest value that
+// the gene can hold. The value is clamped to the range of the gene.
+func (b *BitDNA) SetFloat(name string, v float64) {
+	i := b.gene(name, FloatGene)
+	g := &b.layout.genes[i]
+	if g.Max == g.Min {
+		return
+	}
+	b.setFraction(i, (v-g.Min)/(g.Max-g.Min))
+}
+
+// Int returns the value of the int gene with the given name.
+func (b *BitDNA) Int(name string) int {
+	i := b.gene(name, IntGene)
+	return int(b.layout.genes[i].Min) + int(b.raw(i))
+}
+
+// SetInt sets the int gene with the given name. The value is clamped to the
+// range of the gene.
+func (b *BitDNA) SetInt(name string, v int) {
+	i := b.gene(name, IntGene)
+	g := &b.layout.genes[i]
+	v = max(int(g.Min), min(int(g.Max), v))
+	b.setRaw(i, uint64(v-int(g.Min)))
+}
+
+// Bool returns the value of the bool gene with the given name.
+func (b *BitDNA) Bool(name string) bool {
+	return b.raw(b.gene(name, BoolGene)) == 1
+}
+
+// SetBool sets the bool gene with the given name.
+func (b *BitDNA) SetBool(name string, v bool) {
+	var raw uint64
+	if v {
+		raw = 1
+	}
+	b.setRaw(b.gene(name, BoolGene), raw)
+}
+
+// Enum returns the option of the enum gene with the given name.
+func (b *BitDNA) Enum(name string) string {
+	i := b.gene(name, EnumGene)
+	return b.layout.genes[i].Options[b.raw(i)]
+}
+
+// SetEnum sets the enum gene with the given name to the option. It returns
+// an error if the gene doesn't have the option.
+func (b *BitDNA) SetEnum(name, option string) error {
+	i := b.gene(name, EnumGene)
+	idx := slices.Index(b.layout.genes[i].Options, option)
+	if idx < 0 {
+		return fmt.Errorf("%w: gene %q has no option %q", ErrInvalidDNA, name, option)
+	}
+	b.setRaw(i, uint64(idx))
+	return nil
+}
+
+// MarshalBinary returns the compact encoding of the genes. Use the Decode
+// method of the layout for decoding it.
+func (b *BitDNA) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, len(b.words)*8)
+	for i, w := range b.words {
+		binary.LittleEndian.PutUint64(buf[i*8:], w)
+	}
+	return buf[:(b.layout.bits+7)/8], nil
+}
+
+// DNA exports the genes into the string form of a DNA. Each gene is
+// quantised to the nearest of the values of the alphabet. You should always
+// resolve the DNA object with calling the Resolve() method.
+func (b *BitDNA) DNA() *DNA {
+	d := dnaPool.Get()
+	for i := range b.layout.genes {
+		v := int(math.Round(b.fraction(i) * float64(MaxTraitValue)))
+		d.traits = append(d.traits, rune(alphabet[v]))
+	}
+	return d
+}
+
+// Difference returns the difference between the two BitDNAs as a percentage.
+// It is the mean of the differences of the genes, where the difference of
+// each gene is relative to its range. The enum genes differ either fully or
+// not at all. It returns 100 if the layouts are different.
+func (b *BitDNA) Difference(other *BitDNA) float64 {
+	if b.layout != other.layout {
+		return 100
+	}
+	if len(b.layout.genes) == 0 {
+		return 0
+	}
+	var sum float64
+	for i, g := range b.layout.genes {
+		if g.Kind == EnumGene {
+			if b.raw(i) != other.raw(i) {
+				sum++
+			}
+			continue
+		}
+		sum += math.Abs(b.fraction(i) - other.fraction(i))
+	}
+	return sum / float64(len(b.layout.genes)) * 100
+}
+
+func (b *BitDNA) String() string {
+	d := b.DNA()
+	defer d.Resolve()
+	return d.String()
+}
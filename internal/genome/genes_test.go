@@ -0,0 +1,177 @@
+package genome_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/arsham/neuragene/internal/genome"
+)
+
+func testLayout(t *testing.T) *genome.Layout {
+	t.Helper()
+	l, err := genome.NewLayout(
+		genome.Gene{Name: "turn_rate", Kind: genome.FloatGene, Min: -math.Pi, Max: math.Pi},
+		genome.Gene{Name: "rays", Kind: genome.IntGene, Min: 2, Max: 40},
+		genome.Gene{Name: "nocturnal", Kind: genome.BoolGene},
+		genome.Gene{Name: "diet", Kind: genome.EnumGene, Options: []string{"herbivore", "carnivore", "omnivore"}},
+		genome.Gene{Name: "colour", Kind: genome.FloatGene, Min: 0, Max: 1, Bits: 4},
+	)
+	assert.NoError(t, err)
+	return l
+}
+
+func TestBitDNA(t *testing.T) {
+	t.Parallel()
+	t.Run("Layout", testBitDNALayout)
+	t.Run("Values", testBitDNAValues)
+	t.Run("Binary", testBitDNABinary)
+	t.Run("String", testBitDNAString)
+	t.Run("Difference", testBitDNADifference)
+}
+
+func testBitDNALayout(t *testing.T) {
+	t.Parallel()
+	l := testLayout(t)
+	assert.Equal(t, 16+6+1+2+4, l.Bits())
+	assert.Equal(t, 5, len(l.Genes()))
+	assert.Equal(t, 1, l.Index("rays"))
+	assert.Equal(t, -1, l.Index("wings"))
+
+	tcs := map[string]genome.Gene{
+		"empty name":  {Kind: genome.BoolGene},
+		"kind":        {Name: "a", Kind: 10},
+		"range":       {Name: "a", Kind: genome.FloatGene, Min: 1, Max: 0},
+		"bits":        {Name: "a", Kind: genome.FloatGene, Bits: 33},
+		"int range":   {Name: "a", Kind: genome.IntGene, Min: 0.5, Max: 2},
+		"large range": {Name: "a", Kind: genome.IntGene, Max: 1 << 40},
+		"no options":  {Name: "a", Kind: genome.EnumGene},
+	}
+	for name, g := range tcs {
+		_, err := genome.NewLayout(g)
+		assert.True(t, errors.Is(err, genome.ErrInvalidSchema), "%s: %v", name, err)
+	}
+	_, err := genome.NewLayout(genome.Gene{Name: "a"}, genome.Gene{Name: "a"})
+	assert.True(t, errors.Is(err, genome.ErrInvalidSchema))
+}
+
+func testBitDNAValues(t *testing.T) {
+	t.Parallel()
+	b := testLayout(t).New()
+	assert.Equal(t, -math.Pi, b.Float("turn_rate"))
+	assert.Equal(t, 2, b.Int("rays"))
+	assert.Equal(t, "herbivore", b.Enum("diet"))
+
+	b.SetFloat("turn_rate", 0.1234)
+	assert.True(t, math.Abs(b.Float("turn_rate")-0.1234) < 2*math.Pi/65535, "got %f", b.Float("turn_rate"))
+	b.SetFloat("turn_rate", 10)
+	assert.Equal(t, math.Pi, b.Float("turn_rate"))
+	b.SetInt("rays", 17)
+	assert.Equal(t, 17, b.Int("rays"))
+	b.SetInt("rays", 100)
+	assert.Equal(t, 40, b.Int("rays"))
+	b.SetBool("nocturnal", true)
+	assert.True(t, b.Bool("nocturnal"))
+	assert.NoError(t, b.SetEnum("diet", "omnivore"))
+	assert.Equal(t, "omnivore", b.Enum("diet"))
+	assert.True(t, errors.Is(b.SetEnum("diet", "fungivore"), genome.ErrInvalidDNA))
+	b.SetFloat("colour", 0.5)
+	assert.Equal(t, 8.0/15, b.Float("colour"))
+
+	// The neighbouring genes are not affected.
+	assert.Equal(t, math.Pi, b.Float("turn_rate"))
+	assert.Equal(t, 40, b.Int("rays"))
+	assert.True(t, b.Bool("nocturnal"))
+
+	assert.Panics(t, func() { b.Int("turn_rate") })
+	assert.Panics(t, func() { b.Bool("wings") })
+}
+
+func testBitDNABinary(t *testing.T) {
+	t.Parallel()
+	l := testLayout(t)
+	b := l.New()
+	b.SetFloat("turn_rate", 1.5)
+	b.SetInt("rays", 33)
+	b.SetBool("nocturnal", true)
+	assert.NoError(t, b.SetEnum("diet", "carnivore"))
+	b.SetFloat("colour", 1)
+
+	data, err := b.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(data))
+	got, err := l.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, b.Float("turn_rate"), got.Float("turn_rate"))
+	assert.Equal(t, 33, got.Int("rays"))
+	assert.True(t, got.Bool("nocturnal"))
+	assert.Equal(t, "carnivore", got.Enum("diet"))
+	assert.Equal(t, 0.0, b.Difference(got))
+
+	_, err = l.Decode(data[:3])
+	assert.True(t, errors.Is(err, genome.ErrInvalidDNA))
+	// The diet gene is stored in the bits 23 and 24, and it doesn't have a
+	// fourth option.
+	invalid := append([]byte(nil), data...)
+	invalid[2] |= 1 << 7
+	invalid[3] |= 1
+	_, err = l.Decode(invalid)
+	assert.True(t, errors.Is(err, genome.ErrInvalidDNA))
+	padding := append([]byte(nil), data...)
+	padding[3] |= 0x80
+	_, err = l.Decode(padding)
+	assert.True(t, errors.Is(err, genome.ErrInvalidDNA))
+}
+
+func testBitDNAString(t *testing.T) {
+	t.Parallel()
+	l := testLayout(t)
+	dna := genome.NewDNAFromString("1Zaaa")
+	defer dna.Resolve()
+	b, err := l.FromDNA(dna)
+	assert.NoError(t, err)
+	assert.Equal(t, -math.Pi, b.Float("turn_rate"))
+	assert.Equal(t, 40, b.Int("rays"))
+	assert.False(t, b.Bool("nocturnal"))
+	assert.Equal(t, "1Z", b.String()[:2])
+
+	exported := b.DNA()
+	defer exported.Resolve()
+	again, err := l.FromDNA(exported)
+	assert.NoError(t, err)
+	assert.Equal(t, exported.String(), again.String())
+
+	short := genome.NewDNAFromString("1Z")
+	defer short.Resolve()
+	_, err = l.FromDNA(short)
+	assert.True(t, errors.Is(err, genome.ErrInvalidDNA))
+	invalid := genome.NewDNAFromString("1Z0aa")
+	defer invalid.Resolve()
+	_, err = l.FromDNA(invalid)
+	assert.True(t, errors.Is(err, genome.ErrInvalidDNA))
+
+	schema, err := genome.LayoutFromSchema(genome.Traits, 12)
+	assert.NoError(t, err)
+	assert.Equal(t, genome.Traits.Len()*12, schema.Bits())
+	random := genome.Traits.Default()
+	defer random.Resolve()
+	fromSchema, err := schema.FromDNA(random)
+	assert.NoError(t, err)
+	assert.True(t, math.Abs(genome.ScaleTrait.Express(random)-fromSchema.Float("scale")) < 1e-3)
+}
+
+func testBitDNADifference(t *testing.T) {
+	t.Parallel()
+	l := testLayout(t)
+	a := l.New()
+	b := a.Clone()
+	assert.Equal(t, 0.0, a.Difference(b))
+	b.SetFloat("turn_rate", math.Pi)
+	assert.Equal(t, 20.0, a.Difference(b))
+	assert.NoError(t, b.SetEnum("diet", "omnivore"))
+	assert.Equal(t, 40.0, a.Difference(b))
+	assert.Equal(t, a.Difference(b), b.Difference(a))
+	assert.Equal(t, 100.0, a.Difference(testLayout(t).New()))
+	assert.True(t, b.Layout() == l)
+}
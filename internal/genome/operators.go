@@ -0,0 +1,266 @@
+package genome
+
+import "math/rand"
+
+// Crossover combines the DNA of two parents into the DNA of their offspring.
+// The parents should have the same length. The offspring always has the
+// length of the first parent, and any loci that the second parent doesn't
+// have are inherited from the first one. The parents are not changed.
+type Crossover interface {
+	Crossover(p1, p2 *DNA, r *rand.Rand) *DNA
+}
+
+// Mutator changes the DNA in place.
+type Mutator interface {
+	Mutate(dna *DNA, r *rand.Rand)
+}
+
+var (
+	_ Crossover = UniformCrossover{}
+	_ Crossover = SinglePointCrossover{}
+	_ Crossover = TwoPointCrossover{}
+	_ Crossover = DominanceCrossover{}
+	_ Crossover = MaxCrossover{}
+	_ Mutator   = PointMutation{}
+	_ Mutator   = SwapMutation{}
+	_ Mutator   = InsertionMutation{}
+	_ Mutator   = CreepMutation{}
+	_ Mutator   = TraitMutation{}
+	_ Mutator   = RarestMutation{}
+)
+
+// clone returns a new DNA with the same traits.
+func clone(d *DNA) *DNA {
+	c := dnaPool.Get()
+	c.traits = append(c.traits, d.traits...)
+	return c
+}
+
+// UniformCrossover inherits each locus from either parent with the same
+// chance.
+type UniformCrossover struct{}
+
+// Crossover returns the offspring of the parents.
+func (UniformCrossover) Crossover(p1, p2 *DNA, r *rand.Rand) *DNA {
+	c := clone(p1)
+	for i := 0; i < len(c.traits) && i < len(p2.traits); i++ {
+		if r.Intn(2) == 0 {
+			c.traits[i] = p2.traits[i]
+		}
+	}
+	return c
+}
+
+// SinglePointCrossover inherits the loci before a random point from the first
+// parent, and the rest from the second parent.
+type SinglePointCrossover struct{}
+
+// Crossover returns the offspring of the parents.
+func (SinglePointCrossover) Crossover(p1, p2 *DNA, r *rand.Rand) *DNA {
+	c := clone(p1)
+	n := min(len(c.traits), len(p2.traits))
+	point := r.Intn(n + 1)
+	copy(c.traits[point:n], p2.traits[point:n])
+	return c
+}
+
+// TwoPointCrossover inherits the loci between two random points from the
+// second parent, and the rest from the first parent.
+type TwoPointCrossover struct{}
+
+// Crossover returns the offspring of the parents.
+func (TwoPointCrossover) Crossover(p1, p2 *DNA, r *rand.Rand) *DNA {
+	c := clone(p1)
+	n := min(len(c.traits), len(p2.traits))
+	start, end := r.Intn(n+1), r.Intn(n+1)
+	if start > end {
+		start, end = end, start
+	}
+	copy(c.traits[start:end], p2.traits[start:end])
+	return c
+}
+
+// DominanceCrossover inherits each locus according to the dominance rule of
+// its trait in the schema. The loci that are not in the schema, or have an
+// invalid value in either parent, inherit the larger rune, like the
+// MaxCrossover does.
+type DominanceCrossover struct {
+	Schema *Schema
+}
+
+// Crossover returns the offspring of the parents.
+func (d DominanceCrossover) Crossover(p1, p2 *DNA, r *rand.Rand) *DNA {
+	c := clone(p1)
+	for i := 0; i < len(c.traits) && i < len(p2.traits); i++ {
+		t1, t2 := p1.traits[i], p2.traits[i]
+		v1, ok1 := charValues[t1]
+		v2, ok2 := charValues[t2]
+		if i >= d.Schema.Len() || !ok1 || !ok2 {
+			c.traits[i] = max(t1, t2)
+			continue
+		}
+		c.traits[i] = rune(alphabet[d.Schema.traits[i].Inherit(v1, v2, r)])
+	}
+	return c
+}
+
+// MaxCrossover inherits the larger rune of the parents at each locus.
+type MaxCrossover struct{}
+
+// Crossover returns the offspring of the parents.
+func (MaxCrossover) Crossover(p1, p2 *DNA, _ *rand.Rand) *DNA {
+	c := clone(p1)
+	for i := 0; i < len(c.traits) && i < len(p2.traits); i++ {
+		c.traits[i] = max(c.traits[i], p2.traits[i])
+	}
+	return c
+}
+
+// PointMutation replaces each locus with a random value with the chance of
+// the rate.
+type PointMutation struct {
+	// Rate is the chance of each locus mutating, in the [0, 1] range.
+	Rate float64
+}
+
+// Mutate mutates the DNA in place.
+func (p PointMutation) Mutate(dna *DNA, r *rand.Rand) {
+	for i := range dna.traits {
+		if r.Float64() < p.Rate {
+			dna.traits[i] = rune(alphabet[r.Intn(len(alphabet))])
+		}
+	}
+}
+
+// SwapMutation swaps the values of two random loci with the chance of the
+// rate.
+type SwapMutation struct {
+	// Rate is the chance of the DNA mutating, in the [0, 1] range.
+	Rate float64
+}
+
+// Mutate mutates the DNA in place.
+func (s SwapMutation) Mutate(dna *DNA, r *rand.Rand) {
+	if len(dna.traits) < 2 || r.Float64() >= s.Rate {
+		return
+	}
+	i, j := r.Intn(len(dna.traits)), r.Intn(len(dna.traits))
+	dna.traits[i], dna.traits[j] = dna.traits[j], dna.traits[i]
+}
+
+// InsertionMutation moves the value of a random locus to another random
+// position with the chance of the rate. The values in between are shifted,
+// therefore the length of the DNA doesn't change.
+type InsertionMutation struct {
+	// Rate is the chance of the DNA mutating, in the [0, 1] range.
+	Rate float64
+}
+
+// Mutate mutates the DNA in place.
+func (m InsertionMutation) Mutate(dna *DNA, r *rand.Rand) {
+	if len(dna.traits) < 2 || r.Float64() >= m.Rate {
+		return
+	}
+	from, to := r.Intn(len(dna.traits)), r.Intn(len(dna.traits))
+	v := dna.traits[from]
+	if from < to {
+		copy(dna.traits[from:to], dna.traits[from+1:to+1])
+	} else {
+		copy(dna.traits[to+1:from+1], dna.traits[to:from])
+	}
+	dna.traits[to] = v
+}
+
+// CreepMutation nudges each locus to a nearby value with the chance of the
+// rate. The values stay in the range of the alphabet.
+type CreepMutation struct {
+	// Rate is the chance of each locus mutating, in the [0, 1] range.
+	Rate float64
+	// Step is the largest change of a value. The default value is 1.
+	Step int32
+}
+
+// Mutate mutates the DNA in place. The loci with invalid values are not
+// changed.
+func (c CreepMutation) Mutate(dna *DNA, r *rand.Rand) {
+	t := Trait{Mutation: Mutation{Rate: c.Rate, Step: max(c.Step, 1)}}
+	for i, v := range dna.traits {
+		if value, ok := charValues[v]; ok {
+			dna.traits[i] = rune(alphabet[t.Mutate(value, r)])
+		}
+	}
+}
+
+// TraitMutation mutates each locus according to the mutation behaviour of its
+// trait in the schema. The loci that are not in the schema, or have invalid
+// values, are not changed.
+type TraitMutation struct {
+	Schema *Schema
+}
+
+// Mutate mutates the DNA in place.
+func (m TraitMutation) Mutate(dna *DNA, r *rand.Rand) {
+	for i := 0; i < len(dna.traits) && i < m.Schema.Len(); i++ {
+		if value, ok := charValues[dna.traits[i]]; ok {
+			dna.traits[i] = rune(alphabet[m.Schema.traits[i].Mutate(value, r)])
+		}
+	}
+}
+
+// RarestMutation nudges the first locus of the rune that occurs the least in
+// the DNA to a neighbouring value with the chance of the rate. The values stay
+// in the range of the alphabet, and a locus with an invalid value is not
+// changed.
+type RarestMutation struct {
+	// Rate is the chance of the DNA mutating, in the [0, 1] range.
+	Rate float64
+}
+
+// Mutate mutates the DNA in place.
+func (m RarestMutation) Mutate(dna *DNA, r *rand.Rand) {
+	if len(dna.traits) == 0 || r.Float64() >= m.Rate {
+		return
+	}
+	counts := make(map[rune]int, len(dna.traits))
+	for _, v := range dna.traits {
+		counts[v]++
+	}
+	rarest := 0
+	for i, v := range dna.traits {
+		if counts[v] < counts[dna.traits[rarest]] {
+			rarest = i
+		}
+	}
+	value, ok := charValues[dna.traits[rarest]]
+	if !ok {
+		return
+	}
+	value += int32(r.Intn(2)*2 - 1)
+	dna.traits[rarest] = rune(alphabet[max(0, min(int32(MaxTraitValue), value))])
+}
+
+// Reproduction is a strategy for producing offspring. The offspring is
+// produced by the crossover of the parents, and then it goes through all the
+// mutations in order.
+type Reproduction struct {
+	Crossover Crossover
+	Mutations []Mutator
+}
+
+// Offspring returns the offspring of the parents. You should always resolve
+// the DNA object with calling the Resolve() method.
+func (p *Reproduction) Offspring(p1, p2 *DNA, r *rand.Rand) *DNA {
+	c := p.Crossover.Crossover(p1, p2, r)
+	for _, m := range p.Mutations {
+		m.Mutate(c, r)
+	}
+	return c
+}
+
+// DefaultReproduction inherits the larger rune of the parents at each locus,
+// and mutates the rarest rune of the offspring 3 out of 100 times. It is used
+// by the CreateOffspring function.
+var DefaultReproduction = &Reproduction{
+	Crossover: MaxCrossover{},
+	Mutations: []Mutator{RarestMutation{Rate: 0.03}},
+}
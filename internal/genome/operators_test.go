@@ -0,0 +1,296 @@
+package genome_test
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/arsham/neuragene/internal/genome"
+)
+
+func TestCrossover(t *testing.T) {
+	t.Parallel()
+	t.Run("Uniform", testCrossoverUniform)
+	t.Run("SinglePoint", testCrossoverSinglePoint)
+	t.Run("TwoPoint", testCrossoverTwoPoint)
+	t.Run("Dominance", testCrossoverDominance)
+	t.Run("Max", testCrossoverMax)
+	t.Run("Lengths", testCrossoverLengths)
+}
+
+const (
+	parent1 = "aaaaaaaaaaaaaaaaaaaa"
+	parent2 = "bbbbbbbbbbbbbbbbbbbb"
+)
+
+func offspring(t *testing.T, c genome.Crossover, r *rand.Rand) string {
+	t.Helper()
+	p1 := genome.NewDNAFromString(parent1)
+	p2 := genome.NewDNAFromString(parent2)
+	defer p1.Resolve()
+	defer p2.Resolve()
+	child := c.Crossover(p1, p2, r)
+	defer child.Resolve()
+	assert.Equal(t, parent1, p1.String(), "the parents should not change")
+	assert.Equal(t, parent2, p2.String(), "the parents should not change")
+	return child.String()
+}
+
+func testCrossoverUniform(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	got := offspring(t, genome.UniformCrossover{}, r)
+	assert.Equal(t, len(parent1), len(got))
+	assert.True(t, strings.Contains(got, "a") && strings.Contains(got, "b"), got)
+	assert.Equal(t, "", strings.Trim(got, "ab"))
+}
+
+func testCrossoverSinglePoint(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		got := offspring(t, genome.SinglePointCrossover{}, r)
+		point := strings.Index(got+"b", "b")
+		assert.Equal(t, parent1[:point]+parent2[point:], got)
+	}
+}
+
+func testCrossoverTwoPoint(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		got := offspring(t, genome.TwoPointCrossover{}, r)
+		start := strings.Index(got+"b", "b")
+		end := start + len(got[start:]) - len(strings.TrimLeft(got[start:], "b"))
+		assert.Equal(t, parent1[:start]+parent2[start:end]+parent1[end:], got)
+	}
+}
+
+func testCrossoverDominance(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	schema := genome.MustSchema(
+		&genome.Trait{Name: "dominant", Dominance: genome.Dominant},
+		&genome.Trait{Name: "recessive", Dominance: genome.Recessive},
+		&genome.Trait{Name: "codominant", Dominance: genome.Codominant},
+	)
+	p1 := genome.NewDNAFromString("acac")
+	p2 := genome.NewDNAFromString("cacb")
+	defer p1.Resolve()
+	defer p2.Resolve()
+	child := genome.DominanceCrossover{Schema: schema}.Crossover(p1, p2, r)
+	defer child.Resolve()
+	assert.Equal(t, "cabc", child.String())
+}
+
+func testCrossoverMax(t *testing.T) {
+	t.Parallel()
+	p1 := genome.NewDNAFromString("1aZb")
+	p2 := genome.NewDNAFromString("9bYa")
+	defer p1.Resolve()
+	defer p2.Resolve()
+	child := genome.MaxCrossover{}.Crossover(p1, p2, nil)
+	defer child.Resolve()
+	assert.Equal(t, "9bZb", child.String())
+}
+
+func testCrossoverLengths(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	p1 := genome.NewDNAFromString("aaaaaa")
+	p2 := genome.NewDNAFromString("bbb")
+	defer p1.Resolve()
+	defer p2.Resolve()
+	crossovers := []genome.Crossover{
+		genome.UniformCrossover{},
+		genome.SinglePointCrossover{},
+		genome.TwoPointCrossover{},
+		genome.DominanceCrossover{Schema: genome.Traits},
+	}
+	for _, c := range crossovers {
+		for i := 0; i < 20; i++ {
+			child := c.Crossover(p1, p2, r)
+			assert.Equal(t, "aaa", child.String()[3:])
+			child.Resolve()
+		}
+	}
+}
+
+func TestMutator(t *testing.T) {
+	t.Parallel()
+	t.Run("Point", testMutatorPoint)
+	t.Run("Swap", testMutatorSwap)
+	t.Run("Insertion", testMutatorInsertion)
+	t.Run("Creep", testMutatorCreep)
+	t.Run("Trait", testMutatorTrait)
+	t.Run("Rarest", testMutatorRarest)
+	t.Run("Reproduction", testMutatorReproduction)
+}
+
+// sorted returns the runes of the string in order, for checking that a
+// mutation only moves the values.
+func sorted(s string) string {
+	runes := []rune(s)
+	slices.Sort(runes)
+	return string(runes)
+}
+
+func testMutatorPoint(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	dna := genome.NewDNAFromString(parent1)
+	defer dna.Resolve()
+	genome.PointMutation{Rate: 0}.Mutate(dna, r)
+	assert.Equal(t, parent1, dna.String())
+	genome.PointMutation{Rate: 1}.Mutate(dna, r)
+	assert.NotEqual(t, parent1, dna.String())
+	assert.NoError(t, genome.MustSchema(traitsOf(len(parent1))...).Validate(dna))
+}
+
+// traitsOf returns count traits with default declarations.
+func traitsOf(count int) []*genome.Trait {
+	ret := make([]*genome.Trait, count)
+	for i := range ret {
+		ret[i] = &genome.Trait{Name: string(rune('a' + i))}
+	}
+	return ret
+}
+
+func testMutatorSwap(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	const value = "123456789abcdefghijk"
+	dna := genome.NewDNAFromString(value)
+	defer dna.Resolve()
+	changed := false
+	for i := 0; i < 10; i++ {
+		genome.SwapMutation{Rate: 1}.Mutate(dna, r)
+		assert.Equal(t, sorted(value), sorted(dna.String()))
+		changed = changed || dna.String() != value
+	}
+	assert.True(t, changed)
+}
+
+func testMutatorInsertion(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	const value = "123456789abcdefghijk"
+	for i := 0; i < 50; i++ {
+		dna := genome.NewDNAFromString(value)
+		genome.InsertionMutation{Rate: 1}.Mutate(dna, r)
+		got := dna.String()
+		dna.Resolve()
+		assert.Equal(t, sorted(value), sorted(got))
+		// Removing the moved value from both gives the same order.
+		same := false
+		for j := range got {
+			moved := got[j : j+1]
+			if strings.Replace(got, moved, "", 1) == strings.Replace(value, moved, "", 1) {
+				same = true
+			}
+		}
+		assert.True(t, same, "%s is not an insertion of %s", got, value)
+	}
+}
+
+func testMutatorCreep(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	dna := genome.NewDNAFromString("1mZ0")
+	defer dna.Resolve()
+	genome.CreepMutation{Rate: 1}.Mutate(dna, r)
+	got := dna.String()
+	assert.Equal(t, "2", got[0:1])
+	assert.True(t, got[1] == 'l' || got[1] == 'n', got)
+	assert.Equal(t, "Y", got[2:3])
+	assert.Equal(t, "0", got[3:4], "invalid values are not changed")
+}
+
+func testMutatorTrait(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	schema := genome.MustSchema(
+		&genome.Trait{Name: "fixed"},
+		&genome.Trait{Name: "creep", Mutation: genome.Mutation{Rate: 1, Step: 1}},
+	)
+	dna := genome.NewDNAFromString("mmm")
+	defer dna.Resolve()
+	genome.TraitMutation{Schema: schema}.Mutate(dna, r)
+	got := dna.String()
+	assert.Equal(t, "m", got[0:1])
+	assert.True(t, got[1] == 'l' || got[1] == 'n', got)
+	assert.Equal(t, "m", got[2:3], "the loci beyond the schema are not changed")
+}
+
+func testMutatorRarest(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	tcs := map[string][]string{
+		"aaba":  {"aaaa", "aaca"},
+		"1aa1a": {"1aa1a", "2aa1a"},
+		"aa9aa": {"aa8aa", "aaaaa"},
+		"bbzbb": {"bbybb", "bbAbb"},
+		"ZYZ":   {"ZXZ", "ZZZ"},
+		"aaZaa": {"aaYaa", "aaZaa"},
+		"aa0aa": {"aa0aa"},
+	}
+	for dna, want := range tcs {
+		schema := genome.MustSchema(traitsOf(len(dna))...)
+		for i := 0; i < 20; i++ {
+			d := genome.NewDNAFromString(dna)
+			genome.RarestMutation{Rate: 1}.Mutate(d, r)
+			assert.True(t, slices.Contains(want, d.String()), "%s: %s", dna, d)
+			if dna != "aa0aa" {
+				assert.NoError(t, schema.Validate(d))
+			}
+			d.Resolve()
+		}
+	}
+
+	d := genome.NewDNAFromString("aaba")
+	defer d.Resolve()
+	genome.RarestMutation{}.Mutate(d, r)
+	assert.Equal(t, "aaba", d.String())
+}
+
+func testMutatorReproduction(t *testing.T) {
+	t.Parallel()
+	run := func(seed int64) string {
+		r := rand.New(rand.NewSource(seed))
+		p1 := genome.NewDNAFromString(parent1)
+		p2 := genome.NewDNAFromString(parent2)
+		defer p1.Resolve()
+		defer p2.Resolve()
+		reproduction := &genome.Reproduction{
+			Crossover: genome.TwoPointCrossover{},
+			Mutations: []genome.Mutator{
+				genome.PointMutation{Rate: 0.1},
+				genome.SwapMutation{Rate: 0.5},
+			},
+		}
+		child := reproduction.Offspring(p1, p2, r)
+		defer child.Resolve()
+		return child.String()
+	}
+	assert.Equal(t, run(42), run(42), "the same seed gives the same offspring")
+	assert.Equal(t, len(parent1), len(run(1)))
+
+	// The CreateOffspring function is driven by the given source too.
+	create := func(seed int64) string {
+		r := rand.New(rand.NewSource(seed))
+		p1 := genome.NewDNAFromString("1aZ9bY3c")
+		p2 := genome.NewDNAFromString("2bY8cZ4d")
+		defer p1.Resolve()
+		defer p2.Resolve()
+		var ret []string
+		for i := 0; i < 200; i++ {
+			child := genome.CreateOffspring(p1, p2, r)
+			ret = append(ret, child.String())
+			child.Resolve()
+		}
+		return strings.Join(ret, ",")
+	}
+	assert.Equal(t, create(42), create(42), "the same seed gives the same offspring")
+}
@@ -0,0 +1,231 @@
+package genome
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/arsham/neuragene/internal/brain"
+)
+
+// These constants determine the shape of the brain of the organisms.
+const (
+	// InputsPerRay is the number of the brain inputs of each vision ray: the
+	// distance and the kind of the object the ray hits.
+	InputsPerRay = 2
+	// BodyInputs is the number of the brain inputs that sense the organism
+	// itself: its speed and its remaining lifespan.
+	BodyInputs = 2
+	// BrainOutputs is the number of the brain outputs: the turn and the
+	// thrust of the organism.
+	BrainOutputs = 2
+)
+
+// BrainInputs returns the number of the brain inputs of an organism with the
+// given DNA.
+func BrainInputs(dna *DNA) int {
+	return VisionRaysTrait.ExpressInt(dna)*InputsPerRay + BodyInputs
+}
+
+// ErrInvalidGenome is returned when a genome can't be decoded, or its brain
+// doesn't fit its DNA.
+var ErrInvalidGenome = errors.New("invalid genome")
+
+// Genome is the heritable unit of an organism. It bundles the DNA of the body
+// with the NEAT of the brain, so the traits and the behaviour evolve together.
+// The size of the brain is derived from the traits of the DNA.
+type Genome struct {
+	DNA   *DNA
+	Brain *brain.NEAT
+}
+
+// Validate returns an error if the brain doesn't fit the DNA.
+func (g *Genome) Validate() error {
+	if g.DNA == nil || g.Brain == nil {
+		return fmt.Errorf("%w: missing DNA or brain", ErrInvalidGenome)
+	}
+	if in := BrainInputs(g.DNA); g.Brain.Inputs() != in || g.Brain.Outputs() != BrainOutputs {
+		return fmt.Errorf("%w: brain has %d inputs and %d outputs, want %d and %d",
+			ErrInvalidGenome, g.Brain.Inputs(), g.Brain.Outputs(), in, BrainOutputs)
+	}
+	return nil
+}
+
+// Resolve resolves the DNA of the genome. The genome should not be used
+// afterwards.
+func (g *Genome) Resolve() {
+	g.DNA.Resolve()
+	g.DNA = nil
+	g.Brain = nil
+}
+
+// Breeder creates and reproduces the genomes of a population. All genomes of
+// a population should be created by the same breeder, so their brains share
+// the innovation registry and can be crossed over.
+type Breeder struct {
+	// Reproduction produces the DNA of the offspring.
+	Reproduction *Reproduction
+	// Innovations is the innovation registry of the brains.
+	Innovations *brain.Innovations
+	// MutationRate is the mutation rate of the brains, in percent.
+	MutationRate int
+}
+
+// New returns a genome with the DNA and a new brain that fits it. The
+// genome takes the ownership of the DNA.
+func (b *Breeder) New(dna *DNA, r *rand.Rand) *Genome {
+	return &Genome{
+		DNA:   dna,
+		Brain: brain.NewNEATWithInnovations(BrainInputs(dna), BrainOutputs, b.MutationRate, r, b.Innovations),
+	}
+}
+
+// Random returns a genome with a random DNA and a new brain that fits it.
+func (b *Breeder) Random(r *rand.Rand) *Genome {
+	return b.New(NewRandomDNA(r), r)
+}
+
+// Offspring returns the offspring of the parents. The DNA is produced by the
+// Reproduction, and the brain by the crossover of the brains of the parents,
+// followed by a mutation. The fitter parent decides the structure of the
+// brain, therefore the child inherits the vision rays of the fitter parent
+// before the DNA is mutated. If the brains of the parents have different
+// sizes, the child inherits the brain of the fitter parent. If a mutation
+// changes the number of the brain inputs, the child grows a new brain, as the
+// old one can't sense the new rays.
+func (b *Breeder) Offspring(fitter, other *Genome, r *rand.Rand) *Genome {
+	dna := b.Reproduction.Crossover.Crossover(fitter.DNA, other.DNA, r)
+	dna.SetTrait(VisionRaysTrait.Index(), fitter.DNA.TraitAt(VisionRaysTrait.Index()))
+	for _, m := range b.Reproduction.Mutations {
+		m.Mutate(dna, r)
+	}
+	if BrainInputs(dna) != fitter.Brain.Inputs() {
+		return b.New(dna, r)
+	}
+	// The nodes of the brains with different number of inputs have different
+	// IDs, so they can't be matched.
+	nn := fitter.Brain.Clone()
+	if other.Brain.Inputs() == fitter.Brain.Inputs() {
+		nn = brain.Crossover(fitter.Brain, other.Brain)
+	}
+	return &Genome{
+		DNA:   dna,
+		Brain: nn.Mutate(),
+	}
+}
+
+// genomeFormatVersion is the version of the encoded genomes. It should be
+// increased when the format changes in a way that the older versions can't be
+// decoded.
+const genomeFormatVersion = 1
+
+// genomeMagic is the prefix of the binary encoded genomes.
+var genomeMagic = [4]byte{'G', 'N', 'O', 'M'}
+
+// genomeRecord is the JSON form of a genome.
+type genomeRecord struct {
+	Brain   *brain.NEAT `json:"brain"`
+	DNA     string      `json:"dna"`
+	Version int         `json:"version"`
+}
+
+// genomeHeader is the header of the binary form. It is followed by the DNA,
+// and then by the binary form of the brain.
+type genomeHeader struct {
+	Magic   [4]byte
+	Version uint16
+	DNA     uint32
+}
+
+// MarshalJSON returns the JSON encoding of the genome.
+func (g *Genome) MarshalJSON() ([]byte, error) {
+	return json.Marshal(genomeRecord{
+		Version: genomeFormatVersion,
+		DNA:     g.DNA.String(),
+		Brain:   g.Brain,
+	})
+}
+
+// UnmarshalJSON decodes the genome from the data produced by the MarshalJSON
+// method. The brain receives a time seeded random source and its own
+// innovation registry, see the SetRand and SetInnovations methods of the
+// brain.
+func (g *Genome) UnmarshalJSON(data []byte) error {
+	r := genomeRecord{Brain: &brain.NEAT{}}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidGenome, err)
+	}
+	if r.Version != genomeFormatVersion {
+		return fmt.Errorf("%w: version %d, want %d", ErrInvalidGenome, r.Version, genomeFormatVersion)
+	}
+	return g.restore(r.DNA, r.Brain)
+}
+
+// MarshalBinary returns the compact binary encoding of the genome.
+func (g *Genome) MarshalBinary() ([]byte, error) {
+	nn, err := g.Brain.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	dna := g.DNA.String()
+	buf := &bytes.Buffer{}
+	h := genomeHeader{
+		Magic:   genomeMagic,
+		Version: genomeFormatVersion,
+		DNA:     uint32(len(dna)),
+	}
+	if err := binary.Write(buf, binary.LittleEndian, h); err != nil {
+		return nil, fmt.Errorf("encoding genome: %w", err)
+	}
+	buf.WriteString(dna)
+	buf.Write(nn)
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the genome from the data produced by the
+// MarshalBinary method. See the UnmarshalJSON method for the dependencies of
+// the brain that are not encoded.
+func (g *Genome) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	var h genomeHeader
+	if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+		return fmt.Errorf("%w: reading header: %w", ErrInvalidGenome, err)
+	}
+	if h.Magic != genomeMagic {
+		return fmt.Errorf("%w: not a genome", ErrInvalidGenome)
+	}
+	if h.Version != genomeFormatVersion {
+		return fmt.Errorf("%w: version %d, want %d", ErrInvalidGenome, h.Version, genomeFormatVersion)
+	}
+	if int(h.DNA) > r.Len() {
+		return fmt.Errorf("%w: %d bytes of DNA in %d bytes", ErrInvalidGenome, h.DNA, r.Len())
+	}
+	dna := make([]byte, h.DNA)
+	_, _ = r.Read(dna)
+	nn := &brain.NEAT{}
+	if err := nn.UnmarshalBinary(data[len(data)-r.Len():]); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidGenome, err)
+	}
+	return g.restore(string(dna), nn)
+}
+
+// restore validates the decoded values and replaces the genome with them.
+func (g *Genome) restore(dna string, nn *brain.NEAT) error {
+	decoded := &Genome{
+		DNA:   NewDNAFromString(dna),
+		Brain: nn,
+	}
+	if err := decoded.Validate(); err != nil {
+		decoded.DNA.Resolve()
+		return err
+	}
+	if err := Traits.Validate(decoded.DNA); err != nil {
+		decoded.DNA.Resolve()
+		return fmt.Errorf("%w: %w", ErrInvalidGenome, err)
+	}
+	*g = *decoded
+	return nil
+}
@@ -0,0 +1,164 @@
+package genome_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/arsham/neuragene/internal/brain"
+	"github.com/arsham/neuragene/internal/genome"
+)
+
+func breeder(mutation float64) *genome.Breeder {
+	return &genome.Breeder{
+		Reproduction: &genome.Reproduction{
+			Crossover: genome.DominanceCrossover{Schema: genome.Traits},
+			Mutations: []genome.Mutator{genome.PointMutation{Rate: mutation}},
+		},
+		Innovations:  brain.NewInnovations(),
+		MutationRate: 10,
+	}
+}
+
+// withRays returns a default DNA with the given rune for the vision rays
+// trait.
+func withRays(value rune) *genome.DNA {
+	dna := genome.Traits.Default()
+	dna.SetTrait(genome.VisionRaysTrait.Index(), value)
+	return dna
+}
+
+func TestBrainInputs(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, 2*genome.InputsPerRay+genome.BodyInputs, genome.BrainInputs(withRays('1')))
+	assert.Equal(t, 12*genome.InputsPerRay+genome.BodyInputs, genome.BrainInputs(withRays('Z')))
+	// The default value of 30 expresses 2+10*30/61 rays.
+	assert.Equal(t, 7*genome.InputsPerRay+genome.BodyInputs, genome.BrainInputs(genome.Traits.Default()))
+}
+
+func TestBreeder(t *testing.T) {
+	t.Parallel()
+	t.Run("Random", testBreederRandom)
+	t.Run("Offspring", testBreederOffspring)
+	t.Run("Mutation", testBreederMutation)
+}
+
+func testBreederRandom(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	b := breeder(0)
+	for i := 0; i < 50; i++ {
+		g := b.Random(r)
+		assert.NoError(t, g.Validate())
+		assert.NoError(t, genome.Traits.Validate(g.DNA))
+		g.Resolve()
+	}
+}
+
+func testBreederOffspring(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	b := breeder(0)
+	fitter := b.New(withRays('1'), r)
+	other := b.New(withRays('Z'), r)
+	twin := b.New(withRays('1'), r)
+	for i := 0; i < 50; i++ {
+		child := b.Offspring(fitter, other, r)
+		assert.NoError(t, child.Validate())
+		assert.Equal(t, fitter.DNA.TraitAt(genome.VisionRaysTrait.Index()), child.DNA.TraitAt(genome.VisionRaysTrait.Index()),
+			"the rays are inherited with the brain")
+		assert.Equal(t, fitter.Brain.Inputs(), child.Brain.Inputs())
+
+		child = b.Offspring(fitter, twin, r)
+		assert.NoError(t, child.Validate())
+	}
+}
+
+func testBreederMutation(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	b := breeder(1)
+	fitter := b.Random(r)
+	other := b.Random(r)
+	resized := 0
+	for i := 0; i < 50; i++ {
+		child := b.Offspring(fitter, other, r)
+		assert.NoError(t, child.Validate())
+		if child.Brain.Inputs() != fitter.Brain.Inputs() {
+			resized++
+		}
+	}
+	assert.True(t, resized > 0, "the mutations change the size of the brain")
+}
+
+func TestGenomeEncoding(t *testing.T) {
+	t.Parallel()
+	t.Run("JSON", testGenomeEncodingJSON)
+	t.Run("Binary", testGenomeEncodingBinary)
+	t.Run("Invalid", testGenomeEncodingInvalid)
+}
+
+func assertSameGenome(t *testing.T, want, got *genome.Genome) {
+	t.Helper()
+	assert.Equal(t, want.DNA.String(), got.DNA.String())
+	w, err := json.Marshal(want.Brain)
+	assert.NoError(t, err)
+	g, err := json.Marshal(got.Brain)
+	assert.NoError(t, err)
+	assert.Equal(t, string(w), string(g))
+}
+
+func testGenomeEncodingJSON(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	g := breeder(0).Random(r)
+	data, err := json.Marshal(g)
+	assert.NoError(t, err)
+
+	var decoded genome.Genome
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assertSameGenome(t, g, &decoded)
+}
+
+func testGenomeEncodingBinary(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	g := breeder(0).Random(r)
+	data, err := g.MarshalBinary()
+	assert.NoError(t, err)
+
+	var decoded genome.Genome
+	assert.NoError(t, decoded.UnmarshalBinary(data))
+	assertSameGenome(t, g, &decoded)
+}
+
+func testGenomeEncodingInvalid(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	g := breeder(0).Random(r)
+	data, err := g.MarshalBinary()
+	assert.NoError(t, err)
+
+	var decoded genome.Genome
+	tcs := map[string][]byte{
+		"empty":     {},
+		"magic":     append([]byte("NEAT"), data[4:]...),
+		"truncated": data[:len(data)-1],
+		"dna":       data[:12],
+	}
+	for name, tc := range tcs {
+		err := decoded.UnmarshalBinary(tc)
+		assert.True(t, errors.Is(err, genome.ErrInvalidGenome), "%s: %v", name, err)
+	}
+
+	// The brain doesn't fit a DNA with more rays.
+	g.DNA.SetTrait(genome.VisionRaysTrait.Index(), 'Z')
+	data, err = json.Marshal(g)
+	assert.NoError(t, err)
+	err = json.Unmarshal(data, &decoded)
+	assert.True(t, errors.Is(err, genome.ErrInvalidGenome), err)
+	err = json.Unmarshal([]byte(`{"version":1,"dna":"1111111"}`), &decoded)
+	assert.True(t, errors.Is(err, genome.ErrInvalidGenome), err)
+}
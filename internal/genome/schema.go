@@ -0,0 +1,258 @@
+package genome
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// Dominance is the rule that decides which of the two parents' values of a
+// trait is inherited.
+type Dominance uint8
+
+const (
+	// Dominant inherits the larger value.
+	Dominant Dominance = iota
+	// Recessive inherits the smaller value.
+	Recessive
+	// Codominant inherits the average of the values.
+	Codominant
+	// Random inherits either value with the same chance.
+	Random
+)
+
+func (d Dominance) String() string {
+	switch d {
+	case Dominant:
+		return "Dominant"
+	case Recessive:
+		return "Recessive"
+	case Codominant:
+		return "Codominant"
+	case Random:
+		return "Random"
+	}
+	return "Unknown"
+}
+
+// Mutation describes how a trait mutates.
+type Mutation struct {
+	// Rate is the chance of the trait mutating in each reproduction, in the
+	// [0, 1] range.
+	Rate float64
+	// Step is the largest amount the value changes in each mutation. If it
+	// is zero, the mutation replaces the value with a random one.
+	Step int32
+}
+
+// Trait declares a trait of the DNA. The value of a trait is the position of
+// its rune in the alphabet, from zero to MaxTraitValue, and it is expressed
+// in the organism linearly in the [Min, Max] range. The position of the trait
+// in the DNA is its position in the schema it is declared in.
+type Trait struct {
+	// Name is the unique name of the trait.
+	Name string
+	// Min is the expressed value of the trait with the value of zero.
+	Min float64
+	// Max is the expressed value of the trait with the value of
+	// MaxTraitValue.
+	Max float64
+	// Default is the value of the trait when the DNA doesn't have a valid
+	// value for it.
+	Default   int32
+	Dominance Dominance
+	Mutation  Mutation
+	// index is the position of the trait in its schema plus one, so the zero
+	// value means the trait is not in a schema.
+	index int
+}
+
+// Index returns the position of the trait in the DNA. It returns -1 if the
+// trait is not in a schema.
+func (t *Trait) Index() int {
+	return t.index - 1
+}
+
+// Value returns the value of the trait in the DNA. It returns the default
+// value if the DNA is too short, the trait has an invalid rune or the trait
+// is not in a schema.
+func (t *Trait) Value(dna *DNA) int32 {
+	if v, ok := charValues[dna.TraitAt(t.Index())]; ok {
+		return v
+	}
+	return t.Default
+}
+
+// Express returns the expressed value of the trait in the DNA.
+func (t *Trait) Express(dna *DNA) float64 {
+	return t.Min + (t.Max-t.Min)*float64(t.Value(dna))/float64(MaxTraitValue)
+}
+
+// ExpressInt returns the expressed value of the trait in the DNA, rounded to
+// the nearest integer.
+func (t *Trait) ExpressInt(dna *DNA) int {
+	return int(math.Round(t.Express(dna)))
+}
+
+// Inherit returns the value of the trait in the offspring of parents with the
+// given values, according to the dominance rule of the trait.
+func (t *Trait) Inherit(a, b int32, r *rand.Rand) int32 {
+	switch t.Dominance {
+	case Recessive:
+		return min(a, b)
+	case Codominant:
+		return (a + b) / 2
+	case Random:
+		if r.Intn(2) == 0 {
+			return a
+		}
+		return b
+	}
+	return max(a, b)
+}
+
+// Mutate returns the value after applying the mutation behaviour of the
+// trait. The value is returned unchanged if the trait doesn't mutate.
+func (t *Trait) Mutate(v int32, r *rand.Rand) int32 {
+	if r.Float64() >= t.Mutation.Rate {
+		return v
+	}
+	if t.Mutation.Step == 0 {
+		return int32(r.Intn(MaxTraitValue + 1))
+	}
+	delta := r.Int31n(t.Mutation.Step) + 1
+	if r.Intn(2) == 0 {
+		delta = -delta
+	}
+	return max(0, min(int32(MaxTraitValue), v+delta))
+}
+
+// validate returns an error if the trait declaration is not valid.
+func (t *Trait) validate() error {
+	switch {
+	case t.Name == "":
+		return errors.New("empty name")
+	case t.Min > t.Max:
+		return fmt.Errorf("min %f is larger than max %f", t.Min, t.Max)
+	case t.Default < 0 || t.Default > int32(MaxTraitValue):
+		return fmt.Errorf("default %d is out of range", t.Default)
+	case t.Dominance > Random:
+		return fmt.Errorf("unknown dominance %d", t.Dominance)
+	case t.Mutation.Rate < 0 || t.Mutation.Rate > 1:
+		return fmt.Errorf("mutation rate %f is out of range", t.Mutation.Rate)
+	case t.Mutation.Step < 0:
+		return fmt.Errorf("negative mutation step %d", t.Mutation.Step)
+	}
+	return nil
+}
+
+// ErrInvalidSchema is returned when the trait declarations are not valid.
+var ErrInvalidSchema = errors.New("invalid schema")
+
+// ErrInvalidDNA is returned when a DNA doesn't match a schema.
+var ErrInvalidDNA = errors.New("invalid DNA")
+
+// Schema is the set of the traits of a DNA. The position of each trait in the
+// schema is its position in the DNA.
+type Schema struct {
+	byName map[string]*Trait
+	traits []*Trait
+}
+
+// NewSchema returns a schema with the given traits, and sets the index of
+// each trait to its position. It returns an error if any of the traits is not
+// valid, if the names are not unique, or if a trait is already at another
+// position of a schema.
+func NewSchema(traits ...*Trait) (*Schema, error) {
+	s := &Schema{
+		byName: make(map[string]*Trait, len(traits)),
+		traits: traits,
+	}
+	for i, t := range traits {
+		if err := t.validate(); err != nil {
+			return nil, fmt.Errorf("%w: trait %q: %w", ErrInvalidSchema, t.Name, err)
+		}
+		if _, ok := s.byName[t.Name]; ok {
+			return nil, fmt.Errorf("%w: duplicate trait %q", ErrInvalidSchema, t.Name)
+		}
+		if t.index != 0 && t.index != i+1 {
+			return nil, fmt.Errorf("%w: trait %q is at index %d of another schema", ErrInvalidSchema, t.Name, t.Index())
+		}
+		s.byName[t.Name] = t
+	}
+	for i, t := range traits {
+		t.index = i + 1
+	}
+	return s, nil
+}
+
+// MustSchema is like NewSchema, but it panics if the schema is not valid. It
+// should only be used for declaring the schemas at the package level.
+func MustSchema(traits ...*Trait) *Schema {
+	s, err := NewSchema(traits...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Len returns the number of the traits.
+func (s *Schema) Len() int {
+	return len(s.traits)
+}
+
+// Traits returns the traits ordered by their positions.
+func (s *Schema) Traits() []*Trait {
+	return s.traits
+}
+
+// Trait returns the trait with the given name.
+func (s *Schema) Trait(name string) (*Trait, bool) {
+	t, ok := s.byName[name]
+	return t, ok
+}
+
+// Validate returns an error if the DNA doesn't have a valid value for every
+// trait of the schema.
+func (s *Schema) Validate(dna *DNA) error {
+	if len(dna.traits) != len(s.traits) {
+		return fmt.Errorf("%w: %d traits, want %d", ErrInvalidDNA, len(dna.traits), len(s.traits))
+	}
+	for i, r := range dna.traits {
+		if _, ok := charValues[r]; !ok {
+			return fmt.Errorf("%w: trait %q has invalid value %q", ErrInvalidDNA, s.traits[i].Name, r)
+		}
+	}
+	return nil
+}
+
+// Default returns a DNA with the default values of all traits. You should
+// always resolve the DNA object with calling the Resolve() method.
+func (s *Schema) Default() *DNA {
+	d := dnaPool.Get()
+	for _, t := range s.traits {
+		d.traits = append(d.traits, rune(alphabet[t.Default]))
+	}
+	return d
+}
+
+// Random returns a DNA with random values for all traits. You should always
+// resolve the DNA object with calling the Resolve() method.
+func (s *Schema) Random(r *rand.Rand) *DNA {
+	d := dnaPool.Get()
+	for range s.traits {
+		d.traits = append(d.traits, rune(alphabet[r.Intn(len(alphabet))]))
+	}
+	return d
+}
+
+func (s *Schema) String() string {
+	var b strings.Builder
+	for _, t := range s.traits {
+		fmt.Fprintf(&b, "%d %s [%g, %g] default %d %s mutation %g/%d\n",
+			t.Index(), t.Name, t.Min, t.Max, t.Default, t.Dominance, t.Mutation.Rate, t.Mutation.Step)
+	}
+	return b.String()
+}
@@ -0,0 +1,140 @@
+package genome_test
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/arsham/neuragene/internal/genome"
+)
+
+func TestSchema(t *testing.T) {
+	t.Parallel()
+	t.Run("NewSchema", testSchemaNewSchema)
+	t.Run("Traits", testSchemaTraits)
+	t.Run("Validate", testSchemaValidate)
+	t.Run("Express", testSchemaExpress)
+	t.Run("Inherit", testSchemaInherit)
+	t.Run("Mutate", testSchemaMutate)
+}
+
+func testSchemaNewSchema(t *testing.T) {
+	t.Parallel()
+	valid := func(name string) *genome.Trait {
+		return &genome.Trait{Name: name, Max: 1}
+	}
+	tcs := map[string][]*genome.Trait{
+		"empty name":     {valid("")},
+		"duplicate name": {valid("a"), valid("a")},
+		"other schema":   {valid("a"), genome.ScaleTrait},
+		"min and max":    {{Name: "a", Min: 2, Max: 1}},
+		"default":        {{Name: "a", Default: int32(genome.MaxTraitValue) + 1}},
+		"dominance":      {{Name: "a", Dominance: 10}},
+		"mutation rate":  {{Name: "a", Mutation: genome.Mutation{Rate: 2}}},
+		"mutation step":  {{Name: "a", Mutation: genome.Mutation{Step: -1}}},
+	}
+	for name, traits := range tcs {
+		_, err := genome.NewSchema(traits...)
+		assert.True(t, errors.Is(err, genome.ErrInvalidSchema), "%s: %v", name, err)
+	}
+
+	b := valid("b")
+	assert.Equal(t, -1, b.Index())
+	s, err := genome.NewSchema(b, valid("a"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, s.Len())
+	assert.Equal(t, "b", s.Traits()[0].Name)
+	assert.Equal(t, 0, b.Index())
+	assert.Panics(t, func() { genome.MustSchema(valid("c"), b) })
+}
+
+func testSchemaTraits(t *testing.T) {
+	t.Parallel()
+	for i, trait := range genome.Traits.Traits() {
+		assert.Equal(t, i, trait.Index())
+		got, ok := genome.Traits.Trait(trait.Name)
+		assert.True(t, ok)
+		assert.True(t, got == trait)
+	}
+	_, ok := genome.Traits.Trait("wings")
+	assert.False(t, ok)
+	assert.True(t, strings.Contains(genome.Traits.String(), "lifespan"))
+}
+
+func testSchemaValidate(t *testing.T) {
+	t.Parallel()
+	dna := genome.Traits.Default()
+	defer dna.Resolve()
+	assert.NoError(t, genome.Traits.Validate(dna))
+
+	short := genome.NewDNAFromString("abc")
+	defer short.Resolve()
+	assert.True(t, errors.Is(genome.Traits.Validate(short), genome.ErrInvalidDNA))
+
+	invalid := genome.NewDNAFromString("abc0ef")
+	defer invalid.Resolve()
+	assert.True(t, errors.Is(genome.Traits.Validate(invalid), genome.ErrInvalidDNA))
+
+	// Invalid values are read as the defaults.
+	assert.Equal(t, genome.ScaleTrait.Default, genome.ScaleTrait.Value(invalid))
+	assert.Equal(t, genome.LifespanTrait.Default, genome.LifespanTrait.Value(short))
+}
+
+func testSchemaExpress(t *testing.T) {
+	t.Parallel()
+	slow := &genome.Trait{Name: "slow", Min: 100, Max: 700}
+	fast := &genome.Trait{Name: "fast", Min: 100, Max: 700}
+	genome.MustSchema(&genome.Trait{Name: "other"}, slow, fast)
+	dna := genome.NewDNAFromString("a1Z")
+	defer dna.Resolve()
+	assert.Equal(t, 100.0, slow.Express(dna))
+	assert.Equal(t, 100, slow.ExpressInt(dna))
+	assert.Equal(t, 700.0, fast.Express(dna))
+	assert.Equal(t, int32(genome.MaxTraitValue), fast.Value(dna))
+
+	// The traits that are not in a schema have the default value.
+	unknown := &genome.Trait{Name: "unknown", Max: 1, Default: 3}
+	assert.Equal(t, int32(3), unknown.Value(dna))
+}
+
+func testSchemaInherit(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	tcs := map[genome.Dominance]int32{
+		genome.Dominant:   20,
+		genome.Recessive:  10,
+		genome.Codominant: 15,
+	}
+	for dominance, want := range tcs {
+		trait := &genome.Trait{Dominance: dominance}
+		assert.Equal(t, want, trait.Inherit(10, 20, r), dominance.String())
+	}
+	trait := &genome.Trait{Dominance: genome.Random}
+	seen := map[int32]bool{}
+	for i := 0; i < 100; i++ {
+		seen[trait.Inherit(10, 20, r)] = true
+	}
+	assert.Equal(t, map[int32]bool{10: true, 20: true}, seen)
+}
+
+func testSchemaMutate(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	never := &genome.Trait{}
+	creep := &genome.Trait{Mutation: genome.Mutation{Rate: 1, Step: 2}}
+	reset := &genome.Trait{Mutation: genome.Mutation{Rate: 1}}
+	changed := false
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, int32(30), never.Mutate(30, r))
+		v := creep.Mutate(30, r)
+		assert.True(t, v != 30 && v >= 28 && v <= 32, "got %d", v)
+		v = creep.Mutate(0, r)
+		assert.True(t, v >= 0 && v <= 2, "got %d", v)
+		v = reset.Mutate(30, r)
+		assert.True(t, v >= 0 && v <= int32(genome.MaxTraitValue), "got %d", v)
+		changed = changed || v != 30
+	}
+	assert.True(t, changed)
+}
@@ -1,34 +1,109 @@
 package genome
 
-// These constants determine the index of the trait in the traits array. These
-// should not be accesses from outside of this package, unless for testing.
-const (
-	IndexNutritionCunsumption = iota
-	IndexGrowth
-	IndexMaxGrowth
-)
+import "math/rand"
+
+// alphabet contains the runes that a trait can have, ordered by their values.
+const alphabet = "123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// MaxTraitValue is the largest value a trait can have. The smallest value is
+// zero.
+const MaxTraitValue = len(alphabet) - 1
 
 var charValues = map[rune]int32{}
 
 func init() {
-	runes := "123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	for i, r := range runes {
+	for i, r := range alphabet {
 		charValues[r] = int32(i)
 	}
 }
 
-// NutritionConsumption returns the nutrition consumption trait value for the
-// given DNA.
-func NutritionConsumption(dna *DNA) int32 {
-	return charValues[dna.traits[IndexNutritionCunsumption]]
-}
+// These are the traits of the organisms. A new trait is added by declaring it
+// here and adding it to the Traits schema. The position of the trait in the
+// schema is its position in the DNA.
+var (
+	// NutritionConsumptionTrait is the amount of nutrition the organism
+	// consumes on each frame.
+	NutritionConsumptionTrait = &Trait{
+		Name:      "nutrition_consumption",
+		Min:       0,
+		Max:       1,
+		Default:   30,
+		Dominance: Recessive,
+		Mutation:  Mutation{Rate: 0.03, Step: 2},
+	}
+	// GrowthTrait is the amount the scale of the organism grows on each
+	// frame.
+	GrowthTrait = &Trait{
+		Name:      "growth",
+		Min:       0,
+		Max:       0.001,
+		Default:   30,
+		Dominance: Dominant,
+		Mutation:  Mutation{Rate: 0.03, Step: 2},
+	}
+	// MaxGrowthTrait is the amount the organism can grow beyond its scale at
+	// birth.
+	MaxGrowthTrait = &Trait{
+		Name:      "max_growth",
+		Min:       0,
+		Max:       0.6,
+		Default:   30,
+		Dominance: Dominant,
+		Mutation:  Mutation{Rate: 0.03, Step: 2},
+	}
+	// ScaleTrait is the scale of the organism at birth.
+	ScaleTrait = &Trait{
+		Name:      "scale",
+		Min:       0.3,
+		Max:       0.9,
+		Default:   30,
+		Dominance: Codominant,
+		Mutation:  Mutation{Rate: 0.03, Step: 2},
+	}
+	// SpeedTrait is the maximum length of the velocity of the organism.
+	SpeedTrait = &Trait{
+		Name:      "speed",
+		Min:       100,
+		Max:       700,
+		Default:   30,
+		Dominance: Dominant,
+		Mutation:  Mutation{Rate: 0.03, Step: 2},
+	}
+	// LifespanTrait is the number of frames the organism lives.
+	LifespanTrait = &Trait{
+		Name:      "lifespan",
+		Min:       250,
+		Max:       750,
+		Default:   30,
+		Dominance: Random,
+		Mutation:  Mutation{Rate: 0.03},
+	}
+	// VisionRaysTrait is the number of the rays the organism sees with. Each
+	// ray is an input of the brain.
+	VisionRaysTrait = &Trait{
+		Name:      "vision_rays",
+		Min:       2,
+		Max:       12,
+		Default:   30,
+		Dominance: Codominant,
+		Mutation:  Mutation{Rate: 0.03, Step: 2},
+	}
+)
 
-// Growth returns the growth value when eating food.
-func Growth(dna *DNA) int32 {
-	return charValues[dna.traits[IndexGrowth]]
-}
+// Traits is the schema of the DNA of the organisms.
+var Traits = MustSchema(
+	NutritionConsumptionTrait,
+	GrowthTrait,
+	MaxGrowthTrait,
+	ScaleTrait,
+	SpeedTrait,
+	LifespanTrait,
+	VisionRaysTrait,
+)
 
-// MaxGrowth returns the maximum an organism can grow.
-func MaxGrowth(dna *DNA) int32 {
-	return charValues[dna.traits[IndexMaxGrowth]]
+// NewRandomDNA returns a new DNA with random values for all traits of the
+// Traits schema. You should always resolve the DNA object with calling the
+// Resolve() method.
+func NewRandomDNA(r *rand.Rand) *DNA {
+	return Traits.Random(r)
 }
@@ -0,0 +1,338 @@
+// Package lineage records the pedigree of the organisms. Each organism is
+// recorded when it is born with its parents, and when it dies with the cause
+// of its death. The records can be queried for the ancestors and descendants
+// of an organism, and exported as a phylogenetic tree for offline analysis.
+package lineage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Cause is the cause of death of an organism.
+type Cause uint8
+
+const (
+	// Unknown is the cause of the organisms that died for unknown reasons.
+	Unknown Cause = iota
+	// OldAge is the cause of the organisms that reached the end of their
+	// lifespan.
+	OldAge
+	// Starvation is the cause of the organisms that ran out of nutrition.
+	Starvation
+	// Killed is the cause of the organisms that were killed by other
+	// organisms.
+	Killed
+)
+
+func (c Cause) String() string {
+	switch c {
+	case Unknown:
+		return "Unknown"
+	case OldAge:
+		return "OldAge"
+	case Starvation:
+		return "Starvation"
+	case Killed:
+		return "Killed"
+	}
+	return "Invalid"
+}
+
+// NoSpecies is the species of the organisms that don't belong to any species,
+// like the founders that are spawned with a random DNA.
+const NoSpecies = -1
+
+// Record is the life of an organism.
+type Record struct {
+	// Parents are the IDs of the parents. The founders of the population have
+	// no parents.
+	Parents []uint64
+	ID      uint64
+	// Birth is the tick the organism was born.
+	Birth int64
+	// Death is the tick the organism died. It is only set if the organism is
+	// dead.
+	Death int64
+	// Species is the species of the organism when it was born, or NoSpecies.
+	Species int
+	// Generation is zero for the founders, and one more than the largest
+	// generation of the parents for the rest.
+	Generation int
+	Cause      Cause
+	Dead       bool
+}
+
+// ErrUnknownOrganism is returned when an organism is not in the store.
+var ErrUnknownOrganism = errors.New("unknown organism")
+
+// ErrInvalidRecord is returned when a birth or death can't be recorded.
+var ErrInvalidRecord = errors.New("invalid record")
+
+// Store keeps the records of all organisms. It is safe to use it
+// concurrently.
+type Store struct {
+	records  map[uint64]*Record
+	children map[uint64][]uint64
+	// founders are the organisms without parents in the order of their
+	// births.
+	founders []uint64
+	mu       sync.RWMutex
+	now      int64
+}
+
+// NewStore returns an empty Store.
+func NewStore() *Store {
+	return &Store{
+		records:  make(map[uint64]*Record),
+		children: make(map[uint64][]uint64),
+	}
+}
+
+// Advance moves the clock of the store one tick forward. It should be called
+// once on every tick of the simulation.
+func (s *Store) Advance() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now++
+}
+
+// Now returns the current tick of the store.
+func (s *Store) Now() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.now
+}
+
+// Birth records the birth of an organism at the current tick. The parents
+// should have already been recorded.
+func (s *Store) Birth(id uint64, species int, parents ...uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.records[id]; ok {
+		return fmt.Errorf("%w: organism %d is already born", ErrInvalidRecord, id)
+	}
+	r := &Record{
+		ID:      id,
+		Parents: slices.Clone(parents),
+		Birth:   s.now,
+		Species: species,
+	}
+	for _, p := range parents {
+		parent, ok := s.records[p]
+		if !ok {
+			return fmt.Errorf("%w: parent %d of %d", ErrUnknownOrganism, p, id)
+		}
+		r.Generation = max(r.Generation, parent.Generation+1)
+	}
+	s.records[id] = r
+	unique := slices.Clone(parents)
+	slices.Sort(unique)
+	for _, p := range slices.Compact(unique) {
+		s.children[p] = append(s.children[p], id)
+	}
+	if len(parents) == 0 {
+		s.founders = append(s.founders, id)
+	}
+	return nil
+}
+
+// Death records the death of an organism at the current tick.
+func (s *Store) Death(id uint64, cause Cause) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.records[id]
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownOrganism, id)
+	}
+	if r.Dead {
+		return fmt.Errorf("%w: organism %d is already dead", ErrInvalidRecord, id)
+	}
+	r.Dead = true
+	r.Death = s.now
+	r.Cause = cause
+	return nil
+}
+
+// Prune removes the records of the dead organisms that don't have any living
+// descendants, since they can't be the ancestors of the future organisms. The
+// dead organisms should not have any more children after they are pruned. It
+// returns the number of the removed records. It should be called periodically
+// in long runs to bound the memory of the store.
+func (s *Store) Prune() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keep := make(map[uint64]bool, len(s.records))
+	var stack []uint64
+	for id, r := range s.records {
+		if !r.Dead {
+			stack = append(stack, id)
+		}
+	}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if keep[id] {
+			continue
+		}
+		keep[id] = true
+		stack = append(stack, s.records[id].Parents...)
+	}
+
+	removed := len(s.records) - len(keep)
+	if removed == 0 {
+		return 0
+	}
+	pruned := func(id uint64) bool { return !keep[id] }
+	for id := range s.records {
+		if !keep[id] {
+			delete(s.records, id)
+			delete(s.children, id)
+		}
+	}
+	for id, children := range s.children {
+		s.children[id] = slices.DeleteFunc(children, pruned)
+	}
+	s.founders = slices.DeleteFunc(s.founders, pruned)
+	return removed
+}
+
+// Len returns the number of the recorded organisms.
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.records)
+}
+
+// Get returns a copy of the record of the organism.
+func (s *Store) Get(id uint64) (Record, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.records[id]
+	if !ok {
+		return Record{}, false
+	}
+	ret := *r
+	ret.Parents = slices.Clone(r.Parents)
+	return ret, true
+}
+
+// Ancestors returns the IDs of all the ancestors of the organism, sorted in
+// ascending order.
+func (s *Store) Ancestors(id uint64) ([]uint64, error) {
+	return s.walk(id, func(r *Record) []uint64 { return r.Parents })
+}
+
+// Descendants returns the IDs of all the descendants of the organism, sorted
+// in ascending order.
+func (s *Store) Descendants(id uint64) ([]uint64, error) {
+	return s.walk(id, func(r *Record) []uint64 { return s.children[r.ID] })
+}
+
+// walk returns the IDs of all the organisms that can be reached from the
+// organism with the next function, excluding the organism itself.
+func (s *Store) walk(id uint64, next func(*Record) []uint64) ([]uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.records[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownOrganism, id)
+	}
+	seen := map[uint64]bool{id: true}
+	queue := []*Record{r}
+	ret := make([]uint64, 0)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, n := range next(current) {
+			if seen[n] {
+				continue
+			}
+			seen[n] = true
+			ret = append(ret, n)
+			queue = append(queue, s.records[n])
+		}
+	}
+	slices.Sort(ret)
+	return ret, nil
+}
+
+// WriteNewick writes the lineage as a phylogenetic tree in the Newick format.
+// A Newick tree has a single parent for each node, therefore the organisms
+// are placed under their first parent. Each node is labelled with the ID of
+// the organism, and the length of its branch is the number of ticks between
+// the births of the organism and its first parent. The trees of the founders
+// are joined under an unlabelled root.
+func (s *Store) WriteNewick(w io.Writer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var b strings.Builder
+	b.WriteByte('(')
+	for i, id := range s.founders {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		s.newick(&b, s.records[id])
+	}
+	b.WriteString(");\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// newick writes the subtree of the organism. It doesn't recurse, so the very
+// deep lineages don't exhaust the stack.
+func (s *Store) newick(b *strings.Builder, root *Record) {
+	// The children of each node are pushed to the stack in reverse, and the
+	// node is written when it is popped the second time.
+	type frame struct {
+		record *Record
+		closed bool
+	}
+	stack := []frame{{record: root}}
+	for len(stack) > 0 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		r := f.record
+		children := s.treeChildren(r.ID)
+		if !f.closed && len(children) > 0 {
+			b.WriteByte('(')
+			stack = append(stack, frame{record: r, closed: true})
+			for i := len(children) - 1; i >= 0; i-- {
+				stack = append(stack, frame{record: children[i]})
+			}
+			continue
+		}
+		if f.closed {
+			b.WriteByte(')')
+		}
+		b.WriteString(strconv.FormatUint(r.ID, 10))
+		if len(r.Parents) > 0 {
+			parent := s.records[r.Parents[0]]
+			b.WriteByte(':')
+			b.WriteString(strconv.FormatInt(r.Birth-parent.Birth, 10))
+		}
+		// A sibling follows unless this was the last child of its parent,
+		// in which case the parent is next in the stack to be closed.
+		if len(stack) > 0 && !stack[len(stack)-1].closed {
+			b.WriteByte(',')
+		}
+	}
+}
+
+// treeChildren returns the organisms whose first parent is the organism with
+// the given ID, in the order of their births.
+func (s *Store) treeChildren(id uint64) []*Record {
+	var ret []*Record
+	for _, c := range s.children[id] {
+		r := s.records[c]
+		if r.Parents[0] == id {
+			ret = append(ret, r)
+		}
+	}
+	return ret
+}
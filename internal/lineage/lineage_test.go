@@ -0,0 +1,168 @@
+package lineage_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/arsham/neuragene/internal/lineage"
+)
+
+// family returns a store with the following pedigree, where 5 is the
+// offspring of 3 and 4, and 6 is a founder with no offspring:
+//
+//	1 ─┬─ 2
+//	   └─ 3 ─┬─ 5
+//	4 ───────┘
+//	6
+func family(t *testing.T) *lineage.Store {
+	t.Helper()
+	s := lineage.NewStore()
+	assert.NoError(t, s.Birth(1, 0))
+	s.Advance()
+	assert.NoError(t, s.Birth(2, 0, 1))
+	assert.NoError(t, s.Birth(3, 1, 1))
+	assert.NoError(t, s.Birth(4, 1))
+	s.Advance()
+	s.Advance()
+	assert.NoError(t, s.Birth(5, 1, 3, 4))
+	assert.NoError(t, s.Birth(6, 2))
+	return s
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+	t.Run("Birth", testStoreBirth)
+	t.Run("Death", testStoreDeath)
+	t.Run("Ancestors", testStoreAncestors)
+	t.Run("Descendants", testStoreDescendants)
+	t.Run("Newick", testStoreNewick)
+	t.Run("Prune", testStorePrune)
+}
+
+func testStoreBirth(t *testing.T) {
+	t.Parallel()
+	s := family(t)
+	assert.Equal(t, 6, s.Len())
+	assert.Equal(t, int64(3), s.Now())
+
+	r, ok := s.Get(5)
+	assert.True(t, ok)
+	assert.Equal(t, lineage.Record{
+		Parents:    []uint64{3, 4},
+		ID:         5,
+		Birth:      3,
+		Species:    1,
+		Generation: 2,
+	}, r)
+	r.Parents[0] = 100
+	r, _ = s.Get(5)
+	assert.Equal(t, uint64(3), r.Parents[0], "the record is a copy")
+
+	_, ok = s.Get(100)
+	assert.False(t, ok)
+
+	err := s.Birth(5, 0)
+	assert.True(t, errors.Is(err, lineage.ErrInvalidRecord))
+	err = s.Birth(7, 0, 1, 100)
+	assert.True(t, errors.Is(err, lineage.ErrUnknownOrganism))
+	_, ok = s.Get(7)
+	assert.False(t, ok, "the failed birth is not recorded")
+}
+
+func testStoreDeath(t *testing.T) {
+	t.Parallel()
+	s := family(t)
+	s.Advance()
+	assert.NoError(t, s.Death(2, lineage.OldAge))
+	r, _ := s.Get(2)
+	assert.True(t, r.Dead)
+	assert.Equal(t, int64(4), r.Death)
+	assert.Equal(t, lineage.OldAge, r.Cause)
+	assert.Equal(t, "OldAge", r.Cause.String())
+
+	r, _ = s.Get(3)
+	assert.False(t, r.Dead)
+
+	err := s.Death(2, lineage.Killed)
+	assert.True(t, errors.Is(err, lineage.ErrInvalidRecord))
+	err = s.Death(100, lineage.Killed)
+	assert.True(t, errors.Is(err, lineage.ErrUnknownOrganism))
+}
+
+func testStoreAncestors(t *testing.T) {
+	t.Parallel()
+	s := family(t)
+	tcs := map[uint64][]uint64{
+		1: {},
+		2: {1},
+		5: {1, 3, 4},
+		6: {},
+	}
+	for id, want := range tcs {
+		got, err := s.Ancestors(id)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got, "organism %d", id)
+	}
+	_, err := s.Ancestors(100)
+	assert.True(t, errors.Is(err, lineage.ErrUnknownOrganism))
+}
+
+func testStoreDescendants(t *testing.T) {
+	t.Parallel()
+	s := family(t)
+	tcs := map[uint64][]uint64{
+		1: {2, 3, 5},
+		3: {5},
+		4: {5},
+		5: {},
+	}
+	for id, want := range tcs {
+		got, err := s.Descendants(id)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got, "organism %d", id)
+	}
+	_, err := s.Descendants(100)
+	assert.True(t, errors.Is(err, lineage.ErrUnknownOrganism))
+}
+
+func testStoreNewick(t *testing.T) {
+	t.Parallel()
+	var b strings.Builder
+	err := family(t).WriteNewick(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, "((2:1,(5:2)3:1)1,4,6);\n", b.String())
+
+	b.Reset()
+	err = lineage.NewStore().WriteNewick(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, "();\n", b.String())
+}
+
+func testStorePrune(t *testing.T) {
+	t.Parallel()
+	s := family(t)
+	assert.Equal(t, 0, s.Prune(), "all organisms are alive")
+	for _, id := range []uint64{1, 2, 3, 6} {
+		assert.NoError(t, s.Death(id, lineage.OldAge))
+	}
+	// 1 and 3 are dead, but they are the ancestors of 5.
+	assert.Equal(t, 2, s.Prune())
+	assert.Equal(t, 4, s.Len())
+	_, ok := s.Get(2)
+	assert.False(t, ok)
+	got, err := s.Descendants(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{3, 5}, got)
+	b := &strings.Builder{}
+	assert.NoError(t, s.WriteNewick(b))
+	assert.Equal(t, "(((5:2)3:1)1,4);\n", b.String())
+
+	assert.NoError(t, s.Death(5, lineage.Starvation))
+	assert.NoError(t, s.Death(4, lineage.OldAge))
+	assert.Equal(t, 4, s.Prune())
+	assert.Equal(t, 0, s.Len())
+	assert.NoError(t, s.Birth(7, lineage.NoSpecies))
+	assert.Equal(t, 1, s.Len())
+}
@@ -0,0 +1,94 @@
+// Package novelty implements novelty search. Instead of rewarding the
+// networks for reaching an objective, novelty search rewards them for behaving
+// differently from the behaviours that have been seen before. This prevents
+// the population from converging on a local optimum, like ants spinning in
+// place near their spawn point.
+package novelty
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/arsham/neuragene/internal/geom"
+)
+
+// Behaviour is a descriptor of what an organism did during its life. The
+// distance between two behaviours is the Euclidean distance of their values,
+// therefore all behaviours that are compared must have the same length and
+// their values should have similar scales.
+type Behaviour []float64
+
+// Distance returns the Euclidean distance between the two behaviours. It
+// panics if the behaviours have different lengths, since a truncated
+// behaviour is a programming error and can't be compared.
+func (b Behaviour) Distance(other Behaviour) float64 {
+	if len(b) != len(other) {
+		panic(fmt.Sprintf("novelty: comparing behaviours of lengths %d and %d", len(b), len(other)))
+	}
+	var sum float64
+	for i := range b {
+		d := b[i] - other[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+// Tracker records the path of an organism, and describes it as a Behaviour.
+// The zero value is ready to use. In the simulation the Record method should
+// be called with the resolved position of the entity, for example with the
+// result of the component.Position.Vec method, on every tick.
+type Tracker struct {
+	start    geom.Vec
+	last     geom.Vec
+	length   float64
+	maxDist  float64
+	sumDist  float64
+	recorded int
+}
+
+// Record adds the position to the path.
+func (t *Tracker) Record(pos geom.Vec) {
+	if t.recorded == 0 {
+		t.start = pos
+	} else {
+		t.length += pos.Sub(t.last).Len()
+	}
+	t.last = pos
+	dist := pos.Sub(t.start).Len()
+	t.maxDist = max(t.maxDist, dist)
+	t.sumDist += dist
+	t.recorded++
+}
+
+// Reset clears the recorded path.
+func (t *Tracker) Reset() {
+	*t = Tracker{}
+}
+
+// Behaviour returns the descriptor of the recorded path. The values are
+// divided by the scale, which should be about the size of the world so the
+// values stay around the [0, 1] range. The descriptor contains:
+//
+//   - the final position,
+//   - the total length of the path,
+//   - the furthest distance from the start,
+//   - the mean distance from the start.
+//
+// An organism that spins in place has a long path but stays close to its
+// start, which tells it apart from an organism that explores.
+func (t *Tracker) Behaviour(scale float64) Behaviour {
+	if scale == 0 {
+		scale = 1
+	}
+	var mean float64
+	if t.recorded > 0 {
+		mean = t.sumDist / float64(t.recorded)
+	}
+	return Behaviour{
+		t.last.X / scale,
+		t.last.Y / scale,
+		t.length / scale,
+		t.maxDist / scale,
+		mean / scale,
+	}
+}
@@ -0,0 +1,244 @@
+package novelty
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/arsham/neuragene/internal/brain"
+)
+
+// Config is the configuration of an Archive. Any zero values are replaced
+// with the defaults when passed to the NewArchive constructor.
+type Config struct {
+	// K is the number of the nearest neighbours that the sparseness of a
+	// behaviour is measured against. The default value is 15.
+	K int
+	// Threshold is the minimum novelty of a behaviour to be added to the
+	// archive. The default value is 0.1.
+	Threshold float64
+	// MaxSize is the maximum number of behaviours in the archive. When the
+	// archive is full, the oldest behaviours are removed. The default value
+	// is 1000.
+	MaxSize int
+}
+
+func (c *Config) setDefaults() {
+	if c.K == 0 {
+		c.K = 15
+	}
+	if c.Threshold == 0 {
+		c.Threshold = 0.1
+	}
+	if c.MaxSize == 0 {
+		c.MaxSize = 1000
+	}
+}
+
+// ErrInvalidConfig is returned when the configuration is not valid.
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// Archive keeps the novel behaviours of the past generations, and scores new
+// behaviours by their sparseness. The sparseness of a behaviour is the mean
+// distance to its k nearest neighbours among the current generation and the
+// archive.
+type Archive struct {
+	behaviours []Behaviour
+	config     Config
+}
+
+// NewArchive returns an empty Archive with the given configuration.
+func NewArchive(c *Config) (*Archive, error) {
+	config := *c
+	config.setDefaults()
+	if config.K < 1 || config.MaxSize < 1 || config.Threshold < 0 {
+		return nil, fmt.Errorf("%w: k %d, max size %d and threshold %f",
+			ErrInvalidConfig, config.K, config.MaxSize, config.Threshold)
+	}
+	return &Archive{config: config}, nil
+}
+
+// Len returns the number of the behaviours in the archive.
+func (a *Archive) Len() int {
+	return len(a.behaviours)
+}
+
+// Behaviours returns the behaviours in the archive from the oldest to the
+// newest.
+func (a *Archive) Behaviours() []Behaviour {
+	return a.behaviours
+}
+
+// Sparseness returns the mean distance of the behaviour to its k nearest
+// neighbours among the given behaviours and the archive. The behaviour itself
+// should not be among the others.
+func (a *Archive) Sparseness(b Behaviour, others []Behaviour) float64 {
+	distances := make([]float64, 0, len(others)+len(a.behaviours))
+	for _, o := range others {
+		distances = append(distances, b.Distance(o))
+	}
+	for _, o := range a.behaviours {
+		distances = append(distances, b.Distance(o))
+	}
+	return meanNearest(distances, a.config.K)
+}
+
+// meanNearest returns the mean of the k smallest distances. It returns zero
+// if there are no distances.
+func meanNearest(distances []float64, k int) float64 {
+	if len(distances) == 0 {
+		return 0
+	}
+	slices.Sort(distances)
+	k = min(k, len(distances))
+	var sum float64
+	for _, d := range distances[:k] {
+		sum += d
+	}
+	return sum / float64(k)
+}
+
+// Score returns the novelty of each behaviour of a generation, measured
+// against the rest of the generation and the archive. The behaviours with a
+// novelty above the threshold are then added to the archive. The returned
+// values are never negative, therefore they can be used as fitness.
+func (a *Archive) Score(behaviours []Behaviour) []float64 {
+	scores := make([]float64, len(behaviours))
+	distances := make([]float64, 0, len(behaviours)+len(a.behaviours))
+	for i, b := range behaviours {
+		distances = distances[:0]
+		for j, o := range behaviours {
+			if i != j {
+				distances = append(distances, b.Distance(o))
+			}
+		}
+		for _, o := range a.behaviours {
+			distances = append(distances, b.Distance(o))
+		}
+		scores[i] = meanNearest(distances, a.config.K)
+	}
+	for i, b := range behaviours {
+		if scores[i] > a.config.Threshold {
+			a.add(b)
+		}
+	}
+	return scores
+}
+
+// add adds a copy of the behaviour to the archive, and removes the oldest
+// behaviour if the archive is full.
+func (a *Archive) add(b Behaviour) {
+	if len(a.behaviours) >= a.config.MaxSize {
+		a.behaviours = slices.Delete(a.behaviours, 0, 1)
+	}
+	a.behaviours = append(a.behaviours, slices.Clone(b))
+}
+
+// Blend mixes the fitness and the novelty scores of a generation. Both are
+// normalised to the [0, 1] range by their maximum values, and mixed with the
+// given weight of novelty in the [0, 1] range. A weight of zero selects only
+// on fitness, and a weight of one only on novelty.
+func Blend(fitness, novelty []float64, weight float64) []float64 {
+	maxFitness := normaliser(fitness)
+	maxNovelty := normaliser(novelty)
+	ret := make([]float64, len(fitness))
+	for i := range ret {
+		ret[i] = (1-weight)*fitness[i]/maxFitness + weight*novelty[i]/maxNovelty
+	}
+	return ret
+}
+
+// normaliser returns the maximum of the values, or one if the values are all
+// zero or empty.
+func normaliser(values []float64) float64 {
+	if len(values) == 0 {
+		return 1
+	}
+	m := slices.Max(values)
+	if m <= 0 || math.IsInf(m, 0) || math.IsNaN(m) {
+		return 1
+	}
+	return m
+}
+
+// Selector connects novelty search to the selection of a brain.Population.
+// The fitness and the behaviour of each network are collected during a
+// generation, and then they are blended and set as the fitness of the
+// networks before the population is evolved.
+type Selector struct {
+	archive    *Archive
+	fitness    []float64
+	behaviours []Behaviour
+	weight     float64
+}
+
+// NewSelector returns a Selector that scores the behaviours with the archive,
+// and mixes the novelty with the fitness with the given weight. See the Blend
+// function for the weight.
+func NewSelector(archive *Archive, weight float64) (*Selector, error) {
+	if weight < 0 || weight > 1 {
+		return nil, fmt.Errorf("%w: novelty weight %f", ErrInvalidConfig, weight)
+	}
+	return &Selector{
+		archive: archive,
+		weight:  weight,
+	}, nil
+}
+
+// Archive returns the archive of the selector.
+func (s *Selector) Archive() *Archive {
+	return s.archive
+}
+
+// Set records the fitness and the behaviour of the network at the given
+// index of the population. In the simulation this is called when the entity
+// of the network dies.
+func (s *Selector) Set(index int, fitness float64, b Behaviour) {
+	if index >= len(s.fitness) {
+		s.fitness = slices.Grow(s.fitness, index+1-len(s.fitness))[:index+1]
+		s.behaviours = slices.Grow(s.behaviours, index+1-len(s.behaviours))[:index+1]
+	}
+	s.fitness[index] = fitness
+	s.behaviours[index] = b
+}
+
+// Apply scores the recorded behaviours, and sets the blended scores as the
+// fitness of the networks of the population. The networks without a
+// recorded behaviour receive zero. The records are cleared afterwards, so
+// the selector is ready for the next generation.
+func (s *Selector) Apply(p *brain.Population) {
+	size := len(p.Networks())
+	fitness := make([]float64, size)
+	copy(fitness, s.fitness)
+	behaviours := make([]Behaviour, 0, size)
+	indices := make([]int, 0, size)
+	for i := 0; i < size && i < len(s.behaviours); i++ {
+		if s.behaviours[i] != nil {
+			behaviours = append(behaviours, s.behaviours[i])
+			indices = append(indices, i)
+		}
+	}
+	novelty := make([]float64, size)
+	for i, score := range s.archive.Score(behaviours) {
+		novelty[indices[i]] = score
+	}
+	for i, f := range Blend(fitness, novelty, s.weight) {
+		p.SetFitness(i, f)
+	}
+	clear(s.fitness)
+	clear(s.behaviours)
+	s.fitness = s.fitness[:0]
+	s.behaviours = s.behaviours[:0]
+}
+
+// Evaluate calls fn for each network of the population to get its fitness
+// and behaviour, and then applies them to the population. It is the novelty
+// counterpart of the brain.Population.Evaluate method.
+func (s *Selector) Evaluate(p *brain.Population, fn func(*brain.NEAT) (float64, Behaviour)) {
+	for i, n := range p.Networks() {
+		fitness, b := fn(n)
+		s.Set(i, fitness, b)
+	}
+	s.Apply(p)
+}
@@ -0,0 +1,146 @@
+package novelty_test
+
+import (
+	"errors"
+	"math"
+	stdrand "math/rand"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/arsham/neuragene/internal/brain"
+	"github.com/arsham/neuragene/internal/geom"
+	"github.com/arsham/neuragene/internal/novelty"
+)
+
+func TestTracker(t *testing.T) {
+	t.Parallel()
+	var spinner, explorer novelty.Tracker
+	for i := 0; i < 100; i++ {
+		angle := float64(i) * math.Pi / 4
+		spinner.Record(geom.V(100+math.Cos(angle), 100+math.Sin(angle)))
+		explorer.Record(geom.V(100+float64(i), 100))
+	}
+	s := spinner.Behaviour(100)
+	e := explorer.Behaviour(100)
+	assert.Equal(t, 5, len(s))
+	assert.True(t, s[3] < 0.03, "the spinner stays near its start: %v", s)
+	assert.True(t, s[2] > 0.5, "the spinner has a long path: %v", s)
+	assert.Equal(t, 1.99, e[0])
+	assert.Equal(t, 0.99, e[3])
+	assert.True(t, math.Abs(e[2]-0.99) < 1e-9, "path length %v", e[2])
+
+	explorer.Reset()
+	assert.Equal(t, novelty.Behaviour{0, 0, 0, 0, 0}, explorer.Behaviour(1))
+}
+
+func TestBehaviourDistance(t *testing.T) {
+	t.Parallel()
+	a := novelty.Behaviour{0, 0, 1}
+	b := novelty.Behaviour{3, 4, 1}
+	assert.Equal(t, 5.0, a.Distance(b))
+	assert.Equal(t, 5.0, b.Distance(a))
+	assert.Equal(t, 0.0, a.Distance(a))
+
+	// The behaviours with different lengths can't be compared.
+	short := novelty.Behaviour{3, 4}
+	assert.Panics(t, func() { a.Distance(short) })
+	assert.Panics(t, func() { short.Distance(a) })
+	assert.Panics(t, func() { a.Distance(nil) })
+	assert.Equal(t, 0.0, novelty.Behaviour(nil).Distance(nil))
+}
+
+func TestArchive(t *testing.T) {
+	t.Parallel()
+	t.Run("Config", testArchiveConfig)
+	t.Run("Score", testArchiveScore)
+	t.Run("MaxSize", testArchiveMaxSize)
+}
+
+func testArchiveConfig(t *testing.T) {
+	t.Parallel()
+	_, err := novelty.NewArchive(&novelty.Config{K: -1})
+	assert.True(t, errors.Is(err, novelty.ErrInvalidConfig))
+	_, err = novelty.NewArchive(&novelty.Config{Threshold: -1})
+	assert.True(t, errors.Is(err, novelty.ErrInvalidConfig))
+	_, err = novelty.NewSelector(nil, 2)
+	assert.True(t, errors.Is(err, novelty.ErrInvalidConfig))
+}
+
+func testArchiveScore(t *testing.T) {
+	t.Parallel()
+	a, err := novelty.NewArchive(&novelty.Config{K: 2, Threshold: 2})
+	assert.NoError(t, err)
+	behaviours := []novelty.Behaviour{{0, 0}, {0, 1}, {1, 0}, {10, 10}}
+	scores := a.Score(behaviours)
+	assert.Equal(t, 1.0, scores[0])
+	assert.True(t, scores[3] > scores[0], "the outlier is the most novel: %v", scores)
+	assert.Equal(t, 1, a.Len())
+	assert.Equal(t, novelty.Behaviour{10, 10}, a.Behaviours()[0])
+
+	// The archived behaviour is no longer novel.
+	scores = a.Score([]novelty.Behaviour{{10, 10}, {0, 0}})
+	assert.True(t, scores[0] < scores[1], "scores %v", scores)
+	assert.Equal(t, 0.0, a.Sparseness(novelty.Behaviour{10, 10}, nil))
+}
+
+func testArchiveMaxSize(t *testing.T) {
+	t.Parallel()
+	a, err := novelty.NewArchive(&novelty.Config{K: 1, Threshold: 0.5, MaxSize: 3})
+	assert.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		a.Score([]novelty.Behaviour{{float64(i * 10)}, {float64(i*10 + 1)}})
+	}
+	assert.Equal(t, []novelty.Behaviour{{31}, {40}, {41}}, a.Behaviours())
+}
+
+func TestBlend(t *testing.T) {
+	t.Parallel()
+	fitness := []float64{0, 5, 10}
+	scores := []float64{4, 2, 0}
+	assert.Equal(t, []float64{0, 0.5, 1}, novelty.Blend(fitness, scores, 0))
+	assert.Equal(t, []float64{1, 0.5, 0}, novelty.Blend(fitness, scores, 1))
+	assert.Equal(t, []float64{0.5, 0.5, 0.5}, novelty.Blend(fitness, scores, 0.5))
+	assert.Equal(t, []float64{0, 0}, novelty.Blend([]float64{0, 0}, []float64{0, 0}, 0.5))
+}
+
+func TestSelector(t *testing.T) {
+	t.Parallel()
+	r := stdrand.New(stdrand.NewSource(1))
+	c := brain.DefaultPopulationConfig()
+	c.Size, c.Inputs, c.Outputs, c.MutationRate = 20, 2, 2, 30
+	p, err := brain.NewPopulation(c, r)
+	assert.NoError(t, err)
+	archive, err := novelty.NewArchive(&novelty.Config{K: 5})
+	assert.NoError(t, err)
+	s, err := novelty.NewSelector(archive, 1)
+	assert.NoError(t, err)
+	assert.True(t, s.Archive() == archive)
+
+	// The behaviour is where the network takes an ant after a few steps.
+	walk := func(n *brain.NEAT) (float64, novelty.Behaviour) {
+		var tracker novelty.Tracker
+		pos := geom.V(1, 1)
+		for i := 0; i < 10; i++ {
+			tracker.Record(pos)
+			out, err := n.Predict([]float64{pos.X, pos.Y})
+			assert.NoError(t, err)
+			pos = pos.Add(geom.V(math.Tanh(out[0]), math.Tanh(out[1])))
+		}
+		return 0, tracker.Behaviour(10)
+	}
+	for i := 0; i < 5; i++ {
+		s.Evaluate(p, walk)
+		stats, err := p.Evolve()
+		assert.NoError(t, err)
+		assert.Equal(t, 1.0, stats.BestFitness, "the most novel network has the full score")
+	}
+	assert.True(t, archive.Len() > 0)
+
+	// The networks without a recorded behaviour receive zero.
+	s.Set(3, 10, novelty.Behaviour{1, 2, 3, 4, 5})
+	s.Apply(p)
+	stats, err := p.Evolve()
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, stats.BestFitness)
+	assert.Equal(t, 1.0/20, stats.MeanFitness)
+}
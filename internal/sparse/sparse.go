@@ -0,0 +1,191 @@
+// Package sparse provides a generic sparse set for storing the components of
+// entities in dense arrays.
+package sparse
+
+import "slices"
+
+// pageSize is the number of the entity IDs in each page of the sparse index.
+// The pages are allocated when they are needed, therefore the index doesn't
+// grow with the IDs of the entities that never had a value. The pages that
+// become empty are released when the set is compacted.
+const pageSize = 4096
+
+// Set stores values of entities in a dense slice, ordered by the entity IDs.
+// The entity IDs are mapped to the positions in the dense slice through a
+// paged sparse index, so the values can be accessed without hashing and
+// iterated without chasing pointers.
+//
+// The pointers returned by the Get and Set methods point into the dense slice,
+// and they are only valid until the next call to Set or Remove. The Set
+// should not be changed while it is iterated.
+type Set[T any] struct {
+	pages [][]int32
+	// ids are the entity IDs of the values, in ascending order. It also
+	// contains the IDs of the removed values until they are compacted.
+	ids    []uint64
+	values []T
+	// removed is the number of the removed values that are not compacted.
+	removed int
+}
+
+// NewSet returns a new Set with pre-allocated memory by the given size.
+func NewSet[T any](size int) *Set[T] {
+	return &Set[T]{
+		ids:    make([]uint64, 0, size),
+		values: make([]T, 0, size),
+	}
+}
+
+// index returns the position of the entity's value in the dense slice.
+func (s *Set[T]) index(id uint64) (int, bool) {
+	p := id / pageSize
+	if p >= uint64(len(s.pages)) || s.pages[p] == nil {
+		return 0, false
+	}
+	// The positions are stored off by one, so the zero value means the entity
+	// doesn't have a value.
+	i := s.pages[p][id%pageSize]
+	return int(i) - 1, i != 0
+}
+
+// setIndex sets the position of the entity's value in the dense slice. The
+// negative positions remove the entity from the index.
+func (s *Set[T]) setIndex(id uint64, i int) {
+	p := id / pageSize
+	if p >= uint64(len(s.pages)) {
+		s.pages = append(s.pages, make([][]int32, p-uint64(len(s.pages))+1)...)
+	}
+	if s.pages[p] == nil {
+		s.pages[p] = make([]int32, pageSize)
+	}
+	s.pages[p][id%pageSize] = int32(i + 1)
+}
+
+// alive returns true if the value at the given position is not removed.
+func (s *Set[T]) alive(i int) bool {
+	idx, ok := s.index(s.ids[i])
+	return ok && idx == i
+}
+
+// Len returns the number of the values.
+func (s *Set[T]) Len() int {
+	return len(s.ids) - s.removed
+}
+
+// Has returns true if the entity has a value.
+func (s *Set[T]) Has(id uint64) bool {
+	_, ok := s.index(id)
+	return ok
+}
+
+// Get returns a pointer to the value of the entity. It returns false if the
+// entity doesn't have a value.
+func (s *Set[T]) Get(id uint64) (*T, bool) {
+	i, ok := s.index(id)
+	if !ok {
+		return nil, false
+	}
+	return &s.values[i], true
+}
+
+// Set sets the value of the entity and returns a pointer to it. Setting the
+// values in the order of the entity IDs is the fastest, otherwise the values
+// after the entity are shifted to keep the order.
+func (s *Set[T]) Set(id uint64, v T) *T {
+	if i, ok := s.index(id); ok {
+		s.values[i] = v
+		return &s.values[i]
+	}
+	n := len(s.ids)
+	if n == 0 || s.ids[n-1] < id {
+		s.ids = append(s.ids, id)
+		s.values = append(s.values, v)
+		s.setIndex(id, n)
+		return &s.values[n]
+	}
+	i, found := slices.BinarySearch(s.ids, id)
+	if found {
+		// The value was removed, but not compacted yet.
+		s.values[i] = v
+		s.setIndex(id, i)
+		s.removed--
+		return &s.values[i]
+	}
+	s.ids = slices.Insert(s.ids, i, id)
+	s.values = slices.Insert(s.values, i, v)
+	s.setIndex(id, i)
+	for j := i + 1; j < len(s.ids); j++ {
+		// The removed values are still pointing to their old positions.
+		if idx, ok := s.index(s.ids[j]); ok && idx == j-1 {
+			s.setIndex(s.ids[j], j)
+		}
+	}
+	return &s.values[i]
+}
+
+// Remove removes the value of the entity. It is a no-op if the entity doesn't
+// have a value. The removed values are compacted when they take more than
+// half of the dense slice.
+func (s *Set[T]) Remove(id uint64) {
+	i, ok := s.index(id)
+	if !ok {
+		return
+	}
+	s.setIndex(id, -1)
+	var zero T
+	s.values[i] = zero
+	s.removed++
+	if s.removed > len(s.ids)/2 {
+		s.compact()
+	}
+}
+
+// compact removes the removed values from the dense slice, keeping the order
+// of the rest, and releases the pages of the index that don't have any
+// values.
+func (s *Set[T]) compact() {
+	used := make([]bool, len(s.pages))
+	j := 0
+	for i, id := range s.ids {
+		if !s.alive(i) {
+			continue
+		}
+		s.ids[j] = id
+		s.values[j] = s.values[i]
+		s.setIndex(id, j)
+		used[id/pageSize] = true
+		j++
+	}
+	clear(s.values[j:])
+	s.ids = s.ids[:j]
+	s.values = s.values[:j]
+	s.removed = 0
+
+	for p := range s.pages {
+		if !used[p] {
+			s.pages[p] = nil
+		}
+	}
+	n := len(s.pages)
+	for n > 0 && s.pages[n-1] == nil {
+		n--
+	}
+	s.pages = s.pages[:n]
+}
+
+// Each calls fn with all the values in the order of the entity IDs. The
+// values are read from the dense slice, and the index is only checked if
+// there are removed values that are not compacted.
+func (s *Set[T]) Each(fn func(id uint64, v *T)) {
+	if s.removed == 0 {
+		for i, id := range s.ids {
+			fn(id, &s.values[i])
+		}
+		return
+	}
+	for i, id := range s.ids {
+		if s.alive(i) {
+			fn(id, &s.values[i])
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package sparse_test
+
+import (
+	"testing"
+
+	"github.com/arsham/neuragene/internal/sparse"
+)
+
+// component is the size of a typical component, like the Position.
+type component struct {
+	X, Y, VX, VY, Scale, Angle float64
+}
+
+const benchEntities = 10_000
+
+var aSum float64
+
+// BenchmarkSet compares the Set with the map of pointers it replaced in the
+// component manager, with the access patterns of the systems on each frame.
+func BenchmarkSet(b *testing.B) {
+	ids := make([]uint64, benchEntities)
+	set := sparse.NewSet[component](benchEntities)
+	byMap := make(map[uint64]*component, benchEntities)
+	for i := range ids {
+		// Some of the entities are dead, so the IDs have gaps.
+		id := uint64(i*3/2 + 1)
+		ids[i] = id
+		c := component{X: float64(i), VX: 1}
+		set.Set(id, c)
+		byMap[id] = &c
+	}
+
+	b.Run("Map/Lookup", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, id := range ids {
+				c := byMap[id]
+				c.X += c.VX
+				aSum += c.X
+			}
+		}
+	})
+	b.Run("Set/Lookup", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, id := range ids {
+				c, _ := set.Get(id)
+				c.X += c.VX
+				aSum += c.X
+			}
+		}
+	})
+	b.Run("Map/Range", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, c := range byMap {
+				c.X += c.VX
+				aSum += c.X
+			}
+		}
+	})
+	b.Run("Set/Each", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			set.Each(func(_ uint64, c *component) {
+				c.X += c.VX
+				aSum += c.X
+			})
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package sparse_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"github.com/arsham/neuragene/internal/sparse"
+)
+
+// values returns the IDs and the values of the set in the iteration order.
+func values(s *sparse.Set[int]) ([]uint64, []int) {
+	ids := make([]uint64, 0)
+	vals := make([]int, 0)
+	s.Each(func(id uint64, v *int) {
+		ids = append(ids, id)
+		vals = append(vals, *v)
+	})
+	return ids, vals
+}
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+	t.Run("Get", testSetGet)
+	t.Run("Order", testSetOrder)
+	t.Run("Remove", testSetRemove)
+	t.Run("Pages", testSetPages)
+	t.Run("Random", testSetRandom)
+}
+
+func testSetGet(t *testing.T) {
+	t.Parallel()
+	s := sparse.NewSet[int](10)
+	assert.Equal(t, 0, s.Len())
+	_, ok := s.Get(1)
+	assert.False(t, ok)
+
+	v := s.Set(1, 10)
+	assert.Equal(t, 10, *v)
+	*v = 11
+	got, ok := s.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, 11, *got)
+
+	s.Set(1, 12)
+	got, _ = s.Get(1)
+	assert.Equal(t, 12, *got)
+	assert.Equal(t, 1, s.Len())
+
+	// The IDs far apart are in different pages.
+	s.Set(1_000_000, 13)
+	assert.True(t, s.Has(1_000_000))
+	assert.False(t, s.Has(999_999))
+	assert.False(t, s.Has(5_000_000))
+	assert.Equal(t, 2, s.Len())
+}
+
+func testSetOrder(t *testing.T) {
+	t.Parallel()
+	s := sparse.NewSet[int](0)
+	for _, id := range []uint64{5, 2, 8, 1, 9, 3} {
+		s.Set(id, int(id)*10)
+	}
+	ids, vals := values(s)
+	assert.Equal(t, []uint64{1, 2, 3, 5, 8, 9}, ids)
+	assert.Equal(t, []int{10, 20, 30, 50, 80, 90}, vals)
+	for _, id := range ids {
+		v, ok := s.Get(id)
+		assert.True(t, ok)
+		assert.Equal(t, int(id)*10, *v)
+	}
+}
+
+func testSetRemove(t *testing.T) {
+	t.Parallel()
+	s := sparse.NewSet[int](0)
+	for id := uint64(1); id <= 10; id++ {
+		s.Set(id, int(id))
+	}
+	s.Remove(3)
+	s.Remove(3)
+	s.Remove(100)
+	assert.Equal(t, 9, s.Len())
+	assert.False(t, s.Has(3))
+
+	// Setting a removed value revives it in its place.
+	s.Set(3, 30)
+	assert.Equal(t, 10, s.Len())
+	s.Remove(4)
+	s.Remove(7)
+	s.Set(6, 60)
+	ids, vals := values(s)
+	assert.Equal(t, []uint64{1, 2, 3, 5, 6, 8, 9, 10}, ids)
+	assert.Equal(t, []int{1, 2, 30, 5, 60, 8, 9, 10}, vals)
+
+	// Removing most values compacts the set.
+	for _, id := range []uint64{1, 2, 5, 8, 9, 10} {
+		s.Remove(id)
+	}
+	s.Set(4, 40)
+	ids, vals = values(s)
+	assert.Equal(t, []uint64{3, 4, 6}, ids)
+	assert.Equal(t, []int{30, 40, 60}, vals)
+	for i, id := range ids {
+		v, ok := s.Get(id)
+		assert.True(t, ok)
+		assert.Equal(t, vals[i], *v)
+	}
+}
+
+func testSetPages(t *testing.T) {
+	t.Parallel()
+	s := sparse.NewSet[int](0)
+	for id := uint64(1); id <= 10; id++ {
+		s.Set(id*5000, int(id))
+	}
+	// Removing most values compacts the set and releases the empty pages.
+	for id := uint64(2); id <= 10; id++ {
+		s.Remove(id * 5000)
+	}
+	ids, vals := values(s)
+	assert.Equal(t, []uint64{5000}, ids)
+	assert.Equal(t, []int{1}, vals)
+	for id := uint64(2); id <= 10; id++ {
+		assert.False(t, s.Has(id*5000))
+	}
+
+	// The released pages are allocated again when they are needed.
+	s.Set(50_000, 10)
+	s.Set(20_000, 4)
+	ids, vals = values(s)
+	assert.Equal(t, []uint64{5000, 20_000, 50_000}, ids)
+	assert.Equal(t, []int{1, 4, 10}, vals)
+	got, ok := s.Get(20_000)
+	assert.True(t, ok)
+	assert.Equal(t, 4, *got)
+}
+
+func testSetRandom(t *testing.T) {
+	t.Parallel()
+	r := rand.New(rand.NewSource(1))
+	s := sparse.NewSet[int](0)
+	want := make(map[uint64]int)
+	for i := 0; i < 10000; i++ {
+		id := uint64(r.Intn(500) + 1)
+		if r.Intn(3) == 0 {
+			s.Remove(id)
+			delete(want, id)
+			continue
+		}
+		s.Set(id, i)
+		want[id] = i
+	}
+	assert.Equal(t, len(want), s.Len())
+	ids, vals := values(s)
+	for i, id := range ids {
+		assert.Equal(t, want[id], vals[i])
+		if i > 0 {
+			assert.True(t, ids[i-1] < id, "the values are in the entity order")
+		}
+	}
+	assert.Equal(t, len(want), len(ids))
+}
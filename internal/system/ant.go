@@ -10,20 +10,22 @@ import (
 	"github.com/arsham/neuragene/internal/asset"
 	"github.com/arsham/neuragene/internal/component"
 	"github.com/arsham/neuragene/internal/entity"
+	"github.com/arsham/neuragene/internal/genome"
 	"github.com/arsham/neuragene/internal/geom"
+	"github.com/arsham/neuragene/internal/lineage"
 )
 
 // Ant spawns ants when required.
 type Ant struct {
 	noDraw
-	rand         *stdrand.Rand
-	entities     *entity.Manager
-	assets       *asset.Manager
-	sprite       *ebiten.Image
-	components   *component.Manager
+	rand       *stdrand.Rand
+	entities   *entity.Manager
+	assets     *asset.Manager
+	sprite     *ebiten.Image
+	components *component.Manager
+	// Lineage records the birth of the ants if it is set.
+	Lineage      *lineage.Store
 	lastDuration time.Duration
-	MinVelocity  float64
-	MaxVelocity  float64
 	Seed         int64
 	lastSpawn    int64
 	lastFrame    int64
@@ -50,16 +52,14 @@ func (a *Ant) setup(c controller) error {
 		return fmt.Errorf("%w: component manager", ErrInvalidArgument)
 	}
 	a.sprite = a.assets.Sprites()[asset.Ant]
-	if a.MinVelocity == 0 {
-		a.MinVelocity = -400
-	}
-	if a.MaxVelocity == 0 {
-		a.MaxVelocity = 400
-	}
 	return nil
 }
 
-const antMask = entity.Positioned | entity.Lifespan | entity.BoxBounded | entity.Collides
+// antNutrition is the nutrition the ants are born with. An ant with the
+// highest metabolism starves before it reaches the shortest lifespan.
+const antNutrition = 200
+
+const antMask = entity.Positioned | entity.Lifespan | entity.BoxBounded | entity.Collides | entity.HasDNA
 
 // update spawns an ant every 100 frames.
 func (a *Ant) update(state component.State) error {
@@ -72,12 +72,14 @@ func (a *Ant) update(state component.State) error {
 	}
 	a.lastFrame++
 	diff := a.lastFrame - a.lastSpawn
-	a.spawnAnt()
+	if err := a.spawnAnt(); err != nil {
+		return fmt.Errorf("spawning ant: %w", err)
+	}
 	if diff > 30 {
 		a.lastSpawn = a.lastFrame
 		posMap := a.components.Position
 		a.entities.MapByMask(antMask, func(e *entity.Entity) {
-			position := posMap[e.ID]
+			position, _ := posMap.Get(e.ID)
 			coef := float64(1)
 			if a.rand.Intn(100) > 50 {
 				coef = -1
@@ -89,30 +91,45 @@ func (a *Ant) update(state component.State) error {
 	return nil
 }
 
-func (a *Ant) spawnAnt() {
+// spawnAnt spawns an ant with a random DNA. The scale, velocity, lifespan,
+// metabolism and growth of the ant are expressed from its DNA. It returns an
+// error if the birth can't be recorded in the lineage.
+func (a *Ant) spawnAnt() error {
 	ant := a.entities.NewEntity(antMask)
-	x := a.rand.Float64()*(a.MaxVelocity-a.MinVelocity) + a.MinVelocity
-	y := a.rand.Float64()*(a.MaxVelocity-a.MinVelocity) + a.MinVelocity
-	scale := 0.6
 	id := ant.ID
-	a.components.Position[id] = &component.Position{
-		Scale:    scale,
+	dna := genome.NewRandomDNA(a.rand)
+	phenotype := express(dna)
+	a.components.DNA.Set(id, dna)
+	a.components.Phenotype.Set(id, phenotype)
+
+	x := (a.rand.Float64()*2 - 1) * phenotype.MaxVelocity
+	y := (a.rand.Float64()*2 - 1) * phenotype.MaxVelocity
+	a.components.Position.Set(id, component.Position{
+		Scale:    phenotype.Scale,
 		Pos:      geom.P(float64(a.rand.Intn(500)), float64(a.rand.Intn(500))),
 		Velocity: geom.Vec{X: x, Y: y},
 		Angle:    geom.NewRadian(float64(a.rand.Intn(360))),
-	}
-	a.components.Sprite[id] = &component.Sprite{
+	})
+	a.components.Sprite.Set(id, component.Sprite{
 		Name: asset.Ant,
-	}
-	a.components.Lifespan[id] = &component.Lifespan{
-		Total:     500,
-		Remaining: 500,
-	}
+	})
+	a.components.Lifespan.Set(id, component.Lifespan{
+		Total:     phenotype.Lifespan,
+		Remaining: phenotype.Lifespan,
+		Nutrition: antNutrition,
+	})
 
 	b := a.sprite.Bounds()
 	bounds := geom.R(float64(b.Min.X), float64(b.Min.Y), float64(b.Max.X), float64(b.Max.Y))
 
-	a.components.BoundingBox[id] = &component.BoundingBox{Rect: bounds}
+	a.components.BoundingBox.Set(id, component.BoundingBox{Rect: bounds})
+
+	if a.Lineage == nil {
+		return nil
+	}
+	// The spawned ants are the founders of their lineages. They have a random
+	// DNA, therefore they don't belong to any species.
+	return a.Lineage.Birth(id, lineage.NoSpecies)
 }
 
 // avgCalc returns the amount of time it took for the last update.
@@ -66,8 +66,8 @@ func (b *BoundingBox) update(state component.State) error {
 	positions := b.components.Position
 	b.entitties.MapByMask(entity.BoxBounded, func(e *entity.Entity) {
 		id := e.ID
-		boundingBox := boundingBoxes[id]
-		position := positions[id]
+		boundingBox, _ := boundingBoxes.Get(id)
+		position, _ := positions.Get(id)
 		angle := position.Angle
 		if !position.Velocity.IsZero() {
 			angle = position.Velocity.Angle() + math.Pi/2
@@ -66,7 +66,7 @@ func (c *Collision) update(state component.State) error {
 	c.qTree = quadtree.NewQuadTree[uint64](bounds, c.Capacity, 0)
 	c.entitties.MapByMask(entity.Collides|entity.Rigid, func(e *entity.Entity) {
 		id := e.ID
-		pos := positions[id]
+		pos, _ := positions.Get(id)
 		point := quadtree.Point[uint64]{
 			Vec: geom.V(
 				pos.Pos.Resolve().X,
@@ -79,8 +79,8 @@ func (c *Collision) update(state component.State) error {
 
 	c.entitties.MapByMask(entity.Collides, func(e *entity.Entity) {
 		id1 := e.ID
-		bb1 := boundingBoxes[id1]
-		pos1 := positions[id1]
+		bb1, _ := boundingBoxes.Get(id1)
+		pos1, _ := positions.Get(id1)
 		// Half height and width of the entity so we wouldn't need to calculate
 		// them every time.
 		bb1H := bb1.H() * pos1.Scale / 2
@@ -93,8 +93,8 @@ func (c *Collision) update(state component.State) error {
 			if id1 == id2 {
 				return
 			}
-			bb2 := boundingBoxes[id2]
-			pos2 := positions[id2]
+			bb2, _ := boundingBoxes.Get(id2)
+			pos2, _ := positions.Get(id2)
 			bb2H := bb2.H() * pos2.Scale / 2
 			bb2W := bb2.W() * pos2.Scale / 2
 
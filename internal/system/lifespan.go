@@ -1,22 +1,36 @@
 package system
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/arsham/neuragene/internal/component"
+	"github.com/arsham/neuragene/internal/config"
 	"github.com/arsham/neuragene/internal/entity"
+	"github.com/arsham/neuragene/internal/lineage"
 )
 
-// Lifespan system handles the lifespan of entities. You should always use this
-// system before the AI system, otherwise the AI can't collect the dead genes.
+// Lifespan system handles the lifespan of entities. The entities with a
+// phenotype spend their metabolism from their nutrition on each frame, and
+// they die of starvation when it runs out. You should always use this system
+// before the AI system, otherwise the AI can't collect the dead genes.
 type Lifespan struct {
 	noDraw
-	entities     *entity.Manager
-	components   *component.Manager
+	entities   *entity.Manager
+	components *component.Manager
+	// Lineage records the death of the entities if it is set. The clock of
+	// the lineage is advanced on each running frame, and the extinct lineages
+	// are pruned every lineagePruneInterval frames. The entities that were
+	// not born in the lineage are logged and skipped.
+	Lineage      *lineage.Store
 	lastDuration time.Duration
 }
 
+// lineagePruneInterval is the number of the frames between the prunings of
+// the lineage, which keeps the memory of the lineage bounded in long runs.
+const lineagePruneInterval = 600
+
 func (l *Lifespan) String() string { return "Lifespan" }
 
 var _ System = (*Lifespan)(nil)
@@ -43,24 +57,60 @@ func (l *Lifespan) update(state component.State) error {
 	if !all(state, component.StateRunning) {
 		return nil
 	}
+	if l.Lineage != nil {
+		l.Lineage.Advance()
+		if l.Lineage.Now()%lineagePruneInterval == 0 {
+			l.Lineage.Prune()
+		}
+	}
 	// Note that we don't check the state here. We always want to process this,
 	// and then if required we kill the entities.
 	remove := state&component.StateLimitLifespans == component.StateLimitLifespans
-	lifespan := l.components.Lifespan
-	l.entities.MapByMask(entity.Lifespan, func(e *entity.Entity) {
-		id := e.ID
-		lifespan := lifespan[id]
+	phenotypes := l.components.Phenotype
+	var err error
+	l.components.Lifespan.Each(func(id uint64, lifespan *component.Lifespan) {
 		lifespan.Remaining--
+		starving := false
+		if phenotype, ok := phenotypes.Get(id); ok {
+			lifespan.Nutrition -= phenotype.Metabolism
+			starving = lifespan.Nutrition <= 0
+		}
 		if !remove {
 			return
 		}
-		if lifespan.Remaining <= 0 {
-			l.entities.Kill(e)
+		cause := lineage.OldAge
+		switch {
+		case lifespan.Remaining <= 0:
+		case starving:
+			cause = lineage.Starvation
+		default:
+			return
+		}
+		// Killing the entity only marks it, so the set is not changed while
+		// it is iterated.
+		l.entities.KillByID(id)
+		if l.Lineage != nil && err == nil {
+			err = l.recordDeath(id, cause)
 		}
 	})
+	if err != nil {
+		return fmt.Errorf("recording death: %w", err)
+	}
 	return nil
 }
 
+// recordDeath records the death of the entity in the lineage. The entities
+// that are not in the lineage, for example the ones that were spawned without
+// the lineage, are logged and skipped.
+func (l *Lifespan) recordDeath(id uint64, cause lineage.Cause) error {
+	err := l.Lineage.Death(id, cause)
+	if errors.Is(err, lineage.ErrUnknownOrganism) {
+		config.Logger().Warn("recording death", "entity", id, "error", err)
+		return nil
+	}
+	return err
+}
+
 // avgCalc returns the amount of time it took for the last update.
 func (l *Lifespan) avgCalc() time.Duration {
 	return l.lastDuration
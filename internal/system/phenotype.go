@@ -0,0 +1,79 @@
+package system
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/arsham/neuragene/internal/component"
+	"github.com/arsham/neuragene/internal/entity"
+	"github.com/arsham/neuragene/internal/genome"
+)
+
+// express returns the phenotype of an organism with the given DNA.
+func express(dna *genome.DNA) component.Phenotype {
+	scale := genome.ScaleTrait.Express(dna)
+	return component.Phenotype{
+		Scale:       scale,
+		MaxScale:    scale + genome.MaxGrowthTrait.Express(dna),
+		Growth:      genome.GrowthTrait.Express(dna),
+		MaxVelocity: genome.SpeedTrait.Express(dna),
+		Metabolism:  genome.NutritionConsumptionTrait.Express(dna),
+		Lifespan:    genome.LifespanTrait.ExpressInt(dna),
+	}
+}
+
+// Phenotype system applies the expressed traits of the organisms to their
+// components. On each frame the organisms grow until they reach their maximum
+// scale, and their velocity is limited to their maximum velocity. You should
+// use this system before the Position system.
+type Phenotype struct {
+	noDraw
+	entities     *entity.Manager
+	components   *component.Manager
+	lastDuration time.Duration
+}
+
+var _ System = (*Phenotype)(nil)
+
+func (p *Phenotype) String() string { return "Phenotype" }
+
+// setup returns an error if the entity manager or the component manager is
+// nil.
+func (p *Phenotype) setup(c controller) error {
+	p.entities = c.EntityManager()
+	p.components = c.ComponentManager()
+	if p.entities == nil {
+		return fmt.Errorf("%w: entity manager", ErrInvalidArgument)
+	}
+	if p.components == nil {
+		return fmt.Errorf("%w: component manager", ErrInvalidArgument)
+	}
+	return nil
+}
+
+func (p *Phenotype) update(state component.State) error {
+	started := time.Now()
+	defer func() {
+		p.lastDuration = time.Since(started)
+	}()
+	if !all(state, component.StateRunning) {
+		return nil
+	}
+	posMap := p.components.Position
+	p.components.Phenotype.Each(func(id uint64, phenotype *component.Phenotype) {
+		position, ok := posMap.Get(id)
+		if !ok {
+			return
+		}
+		position.Scale = min(position.Scale+phenotype.Growth, phenotype.MaxScale)
+		if speed := position.Velocity.Len(); speed > phenotype.MaxVelocity {
+			position.Velocity = position.Velocity.Scaled(phenotype.MaxVelocity / speed)
+		}
+	})
+	return nil
+}
+
+// avgCalc returns the amount of time it took for the last update.
+func (p *Phenotype) avgCalc() time.Duration {
+	return p.lastDuration
+}
@@ -53,15 +53,15 @@ func (p *Position) update(state component.State) error {
 	// calculate the position of the entity based on the time passed (1/TPS).
 	// An entity can move diagonally, so we need to account for the angle.
 	x, y := ebiten.WindowSize()
-	posMap := p.components.Position
-	p.entities.MapByMask(entity.Positioned, func(e *entity.Entity) {
-		position := posMap[e.ID]
+	container := geom.R(0, 0, float64(x), float64(y))
+	// The positions are iterated in their dense set, which avoids looking up
+	// each entity's position.
+	p.components.Position.Each(func(_ uint64, position *component.Position) {
 		deltaX := position.Velocity.X / 100
 		deltaY := position.Velocity.Y / 100
 		position.Add(deltaX, deltaY)
 
 		// Preventing the entity from going out of the screen.
-		container := geom.R(0, 0, float64(x), float64(y))
 		position.BounceBy(container)
 	})
 	return nil
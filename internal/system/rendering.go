@@ -62,9 +62,9 @@ func (r *Rendering) draw(screen *ebiten.Image, state component.State) {
 	positions := r.components.Position
 	boundingBoxes := r.components.BoundingBox
 	r.entities.MapByMask(entity.Positioned|entity.HasTexture, func(e *entity.Entity) {
-		sprite := sprites[e.ID]
-		position := positions[e.ID]
-		boundingBox := boundingBoxes[e.ID]
+		sprite, _ := sprites.Get(e.ID)
+		position, _ := positions.Get(e.ID)
+		boundingBox, _ := boundingBoxes.Get(e.ID)
 		options := &ebiten.DrawImageOptions{}
 
 		r := boundingBox.Rect
@@ -3,6 +3,7 @@ package system
 import (
 	"cmp"
 	"fmt"
+	stdrand "math/rand"
 	"runtime"
 	"runtime/debug"
 	"slices"
@@ -16,6 +17,7 @@ import (
 
 	"github.com/arsham/neuragene/internal/component"
 	"github.com/arsham/neuragene/internal/entity"
+	"github.com/arsham/neuragene/internal/genome"
 )
 
 type reports interface {
@@ -26,6 +28,8 @@ type reports interface {
 // Stats prints useful statistics every 2 seconds.
 type Stats struct {
 	entities     *entity.Manager
+	components   *component.Manager
+	rand         *stdrand.Rand
 	controller   controller
 	updateTime   time.Time
 	stats        map[string]time.Duration
@@ -41,16 +45,22 @@ var _ System = (*Stats)(nil)
 
 func (s *Stats) String() string { return "Stats" }
 
-// setup returns an error if the entity manager is nil.
+// setup returns an error if the entity manager or the component manager is
+// nil.
 func (s *Stats) setup(c controller) error {
 	s.controller = c
 	s.entities = c.EntityManager()
+	s.components = c.ComponentManager()
 	if s.entities == nil {
 		return fmt.Errorf("%w: entity manager", ErrInvalidArgument)
 	}
+	if s.components == nil {
+		return fmt.Errorf("%w: component manager", ErrInvalidArgument)
+	}
 	if s.controller == nil {
 		return fmt.Errorf("%w: controller", ErrInvalidArgument)
 	}
+	s.rand = stdrand.New(stdrand.NewSource(time.Now().UnixNano()))
 	s.updateTime = time.Now()
 	s.stats = make(map[string]time.Duration, 10)
 	return nil
@@ -143,6 +153,7 @@ func (s *Stats) printStats() {
 	printRuntimeStats()
 	printMemoryStats()
 	s.printEngineStats()
+	s.printGeneticStats()
 	s.printSystemStats()
 	tm.Flush()
 }
@@ -151,6 +162,35 @@ func format(key, val string) string {
 	return fmt.Sprintf("| %-20s | %-20s |", key, val)
 }
 
+// distanceSamples is the number of the pairs of DNAs that are compared for
+// the distance statistics. Comparing all pairs is too slow for large
+// populations.
+const distanceSamples = 1000
+
+// printGeneticStats prints the diversity of the DNA of the living entities. A
+// low heterozygosity or many fixed loci means the population has lost its
+// diversity, and the mutation rate should be raised.
+func (s *Stats) printGeneticStats() {
+	dnas := make([]*genome.DNA, 0, s.entities.Len())
+	s.entities.MapByMask(entity.HasDNA, func(e *entity.Entity) {
+		if dna, ok := s.components.DNA.Get(e.ID); ok {
+			dnas = append(dnas, *dna)
+		}
+	})
+	d := genome.Analyse(dnas)
+	distances := genome.SampleDistances(dnas, distanceSamples, s.rand)
+	loci := len(d.Frequencies)
+	_, _ = tm.Println(format("Genetic Statistics:", ""))
+	_, _ = tm.Println(format("Population:", fmt.Sprintf("%d", d.Size)))
+	_, _ = tm.Println(format("Heterozygosity:", fmt.Sprintf("%.3f", d.MeanHeterozygosity())))
+	_, _ = tm.Println(format("Entropy:", fmt.Sprintf("%.3f bits", d.MeanEntropy())))
+	_, _ = tm.Println(format("Fixed Loci:", fmt.Sprintf("%d/%d", len(d.Fixed(1)), loci)))
+	_, _ = tm.Println(format("Near Fixed Loci:", fmt.Sprintf("%d/%d", len(d.Fixed(0.95)), loci)))
+	_, _ = tm.Println(format("Mean Distance:", fmt.Sprintf("%.2f%%", distances.Mean)))
+	_, _ = tm.Println(format("Distance StdDev:", fmt.Sprintf("%.2f%%", distances.StdDev)))
+	_, _ = tm.Println(strings.Repeat("-", 47))
+}
+
 // avgCalc returns the amount of time it took for the last update.
 func (s *Stats) avgCalc() time.Duration {
 	return s.lastDuration